@@ -0,0 +1,40 @@
+package dafi
+
+// AggregateFunction represents an SQL aggregate function.
+type AggregateFunction string
+
+const (
+	// Count counts the rows or non-null values of a field.
+	Count AggregateFunction = "count"
+	// Sum adds up the values of a field.
+	Sum AggregateFunction = "sum"
+	// Avg averages the values of a field.
+	Avg AggregateFunction = "avg"
+	// Min returns the smallest value of a field.
+	Min AggregateFunction = "min"
+	// Max returns the largest value of a field.
+	Max AggregateFunction = "max"
+)
+
+// Aggregation represents an aggregate over a domain field, e.g. sum of amount.
+type Aggregation struct {
+	Function AggregateFunction `json:"function"`
+	Field    string            `json:"field"`
+	Alias    string            `json:"alias,omitempty"`
+	Distinct bool              `json:"distinct,omitempty"`
+}
+
+// Aggregations represents a collection of aggregations.
+type Aggregations []Aggregation
+
+// IsZero returns true if the aggregations collection is empty.
+func (a Aggregations) IsZero() bool {
+	return len(a) == 0
+}
+
+// As sets the alias of the aggregation.
+func (a Aggregation) As(alias string) Aggregation {
+	a.Alias = alias
+
+	return a
+}
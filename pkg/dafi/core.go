@@ -3,12 +3,14 @@ package dafi
 
 // Criteria defines the complete set of query criteria including selection, joins, filters, sorting, and pagination.
 type Criteria struct {
-	SelectColumns   []string
-	Joins           []string
-	Filters         Filters
-	FiltersByModule map[string]Filters
-	Sorts           Sorts
-	Pagination      Pagination
+	SelectColumns   []string           `json:"selectColumns,omitempty"`
+	Joins           []string           `json:"joins,omitempty"`
+	Filters         Filters            `json:"filters,omitempty"`
+	FiltersByModule map[string]Filters `json:"filtersByModule,omitempty"`
+	Sorts           Sorts              `json:"sorts,omitempty"`
+	Pagination      Pagination         `json:"pagination"`
+	Groups          []string           `json:"groups,omitempty"`
+	Aggregations    Aggregations       `json:"aggregations,omitempty"`
 }
 
 // New creates a new empty Criteria.
@@ -78,3 +80,17 @@ func (c Criteria) Select(columns ...string) Criteria {
 
 	return c
 }
+
+// GroupBy sets the fields to group by.
+func (c Criteria) GroupBy(fields ...string) Criteria {
+	c.Groups = fields
+
+	return c
+}
+
+// Aggregate adds aggregations to be selected.
+func (c Criteria) Aggregate(aggregations ...Aggregation) Criteria {
+	c.Aggregations = append(c.Aggregations, aggregations...)
+
+	return c
+}
@@ -1,5 +1,12 @@
 package dafi
 
+import "regexp"
+
+// MaxJSONPathDepth is the maximum number of segments in a JSON path.
+const MaxJSONPathDepth = 16
+
+var jsonPathSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,63}$`)
+
 type (
 	// FilterField represents the name of the field to filter by.
 	FilterField string
@@ -41,6 +48,20 @@ const (
 	IsNot FilterOperator = "isn"
 	// IsNotNull checks if value is NOT NULL.
 	IsNotNull FilterOperator = "isnnull"
+	// Exists matches when the sub-query in the value returns at least one row. The field is ignored.
+	Exists FilterOperator = "exists"
+	// NotExists matches when the sub-query in the value returns no rows. The field is ignored.
+	NotExists FilterOperator = "nexists"
+	// JSONContains matches JSON documents containing the JSON value (@>).
+	JSONContains FilterOperator = "jcontains"
+	// HasKey matches JSON objects with the key in the value (?).
+	HasKey FilterOperator = "haskey"
+	// HasAnyKey matches JSON objects with any of the keys in the value (?|).
+	HasAnyKey FilterOperator = "hasany"
+	// HasAllKeys matches JSON objects with all the keys in the value (?&).
+	HasAllKeys FilterOperator = "hasall"
+	// JSONPathMatch matches JSON documents for which the jsonpath predicate in the value is true (@@).
+	JSONPathMatch FilterOperator = "jsonpath"
 
 	// Default is used when no operator is specified and the value is already defined with a sub-query.
 	Default FilterOperator = "default"
@@ -57,17 +78,40 @@ const (
 )
 
 // Filter represents a single filter criteria.
+// Path selects a value inside a JSON Field, e.g. Field meta and Path customer, id for meta.customer.id.
 type Filter struct {
-	Module                            string
-	IsGroupOpen                       bool
-	GroupOpenQty                      int
-	Field                             FilterField
-	Operator                          FilterOperator
-	Value                             FilterValue
-	IsGroupClose                      bool
-	GroupCloseQty                     int
-	ChainingKey                       FilterChainingKey
-	OverridePreviousFilterChainingKey FilterChainingKey
+	Module                            string            `json:"module,omitempty"`
+	IsGroupOpen                       bool              `json:"isGroupOpen,omitempty"`
+	GroupOpenQty                      int               `json:"groupOpenQty,omitempty"`
+	Field                             FilterField       `json:"field,omitempty"`
+	Path                              []string          `json:"path,omitempty"`
+	Operator                          FilterOperator    `json:"operator,omitempty"`
+	Value                             FilterValue       `json:"value,omitempty"`
+	IsGroupClose                      bool              `json:"isGroupClose,omitempty"`
+	GroupCloseQty                     int               `json:"groupCloseQty,omitempty"`
+	ChainingKey                       FilterChainingKey `json:"chainingKey,omitempty"`
+	OverridePreviousFilterChainingKey FilterChainingKey `json:"overridePreviousFilterChainingKey,omitempty"`
+}
+
+// IsJSON returns true if the filter targets a JSON document, either through a path or a JSON operator.
+func (f Filter) IsJSON() bool {
+	return len(f.Path) > 0 || f.Operator.IsJSON()
+}
+
+// IsJSON returns true if the operator only applies to JSON documents.
+func (o FilterOperator) IsJSON() bool {
+	switch o {
+	case JSONContains, HasKey, HasAnyKey, HasAllKeys, JSONPathMatch:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsJSONPathSegment returns true if segment is a valid key or array index in a JSON path.
+// Segments are limited to letters, digits, underscores and hyphens.
+func IsJSONPathSegment(segment string) bool {
+	return jsonPathSegmentPattern.MatchString(segment)
 }
 
 // Filters represents a collection of filters.
@@ -2,8 +2,8 @@ package dafi
 
 // Pagination defines the pagination parameters.
 type Pagination struct {
-	PageNumber uint
-	PageSize   uint
+	PageNumber uint `json:"pageNumber,omitempty"`
+	PageSize   uint `json:"pageSize,omitempty"`
 }
 
 // IsZero checks if the pagination is empty.
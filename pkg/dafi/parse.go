@@ -15,12 +15,16 @@ const (
 	parameterLimit  = "limit"
 	parameterSort   = "sort"
 	parameterSelect = "select"
+	parameterGroup  = "group"
+	parameterAgg    = "agg"
 	defaultChaining = And
 )
 
 // QueryParser parses URL values into Criteria.
 type QueryParser struct {
-	operators map[FilterOperator]struct{}
+	operators          map[FilterOperator]struct{}
+	aggregateFunctions map[AggregateFunction]struct{}
+	jsonFields         map[string]struct{}
 }
 
 // NewQueryParser creates a new QueryParser.
@@ -43,8 +47,32 @@ func NewQueryParser() *QueryParser {
 			IsNot:          {},
 			IsNotNull:      {},
 			Default:        {},
+			JSONContains:   {},
+			HasKey:         {},
+			HasAnyKey:      {},
+			HasAllKeys:     {},
+			JSONPathMatch:  {},
 		},
+		aggregateFunctions: map[AggregateFunction]struct{}{
+			Count: {},
+			Sum:   {},
+			Avg:   {},
+			Min:   {},
+			Max:   {},
+		},
+		jsonFields: map[string]struct{}{},
+	}
+}
+
+// WithJSONFields registers fields holding JSON documents, so a key like meta.tier is parsed as the path tier
+// inside meta instead of the field tier of module meta. Paths may be nested, e.g. meta.customer.id, or follow a
+// module, e.g. ledger.meta.tier. Keys that do not start with a JSON field keep their module.field parsing.
+func (p *QueryParser) WithJSONFields(fields ...string) *QueryParser {
+	for _, field := range fields {
+		p.jsonFields[field] = struct{}{}
 	}
+
+	return p
 }
 
 // Parse parses the given URL values.
@@ -85,7 +113,21 @@ func (p *QueryParser) parseValues(key string, values []string, criteria *Criteri
 			continue
 		}
 
-		parts := strings.SplitN(value, ":", 4)
+		if key == parameterGroup {
+			p.parseGroup(value, criteria)
+
+			continue
+		}
+
+		if key == parameterAgg {
+			if err := p.parseAggregations(value, criteria); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		parts := p.splitParts(value)
 		if len(parts) == 1 {
 			continue
 		}
@@ -98,12 +140,35 @@ func (p *QueryParser) parseValues(key string, values []string, criteria *Criteri
 	return nil
 }
 
+// splitParts splits a value into its [chaining:]operator:value[:chaining] parts.
+// JSON documents and jsonpath predicates contain colons, so everything after the JSONContains and
+// JSONPathMatch operators is the value and the chaining key defaults to AND.
+func (p *QueryParser) splitParts(value string) []string {
+	parts := strings.SplitN(value, ":", 4)
+
+	operatorIndex := 0
+	if len(parts) > 2 && (strings.EqualFold(parts[0], string(And)) || strings.EqualFold(parts[0], string(Or))) {
+		operatorIndex = 1
+	}
+
+	switch FilterOperator(parts[operatorIndex]) {
+	case JSONContains, JSONPathMatch:
+		return strings.SplitN(value, ":", operatorIndex+2)
+	default:
+		return parts
+	}
+}
+
 func (p *QueryParser) parsePart(key string, parts []string, criteria *Criteria) error {
 	switch {
 	case p.isPaginationPart(parts):
 		return p.parsePagination(parts, &criteria.Pagination)
 	case p.isSortPart(parts):
-		criteria.Sorts = append(criteria.Sorts, p.parseSort(key, parts))
+		sort, err := p.parseSort(key, parts)
+		if err != nil {
+			return err
+		}
+		criteria.Sorts = append(criteria.Sorts, sort)
 	default:
 		filter, err := p.parseFilter(key, parts)
 		if err != nil {
@@ -166,20 +231,26 @@ func (p *QueryParser) parsePagination(parts []string, pagination *Pagination) er
 	return nil
 }
 
-func (p *QueryParser) parseSort(field string, parts []string) Sort {
+func (p *QueryParser) parseSort(field string, parts []string) (Sort, error) {
+	sortType := SortType(strings.ToUpper(parts[1]))
+	if sortType != Asc && sortType != Desc {
+		return Sort{}, oops.Code("invalid_sort_type").Errorf("invalid sort type: %s", parts[1])
+	}
+
 	return Sort{
 		Field: SortBy(field),
-		Type:  SortType(strings.ToUpper(parts[1])),
-	}
+		Type:  sortType,
+	}, nil
 }
 
-//nolint:unparam // kept for future validation enhancements
-func (p *QueryParser) parseFilter(field string, parts []string) (Filter, error) {
-	// Note: This function currently always returns nil error, but the error return.
-	// is kept for future validation enhancements.
+func (p *QueryParser) parseFilter(key string, parts []string) (Filter, error) {
 	overridePreviousFilterChainingKey := FilterChainingKey("")
 	if len(parts) == 4 {
-		overridePreviousFilterChainingKey = FilterChainingKey(strings.ToUpper(parts[3]))
+		chainingKey, err := p.parseChainingKey(key, parts[3])
+		if err != nil {
+			return Filter{}, err
+		}
+		overridePreviousFilterChainingKey = chainingKey
 		parts = parts[1:]
 	}
 	if len(parts) == 3 && (strings.EqualFold(parts[0], string(And)) || strings.EqualFold(parts[0], string(Or))) {
@@ -188,24 +259,25 @@ func (p *QueryParser) parseFilter(field string, parts []string) (Filter, error)
 	}
 
 	operator := p.determineOperator(parts[0])
-	chainingKey := p.determineChainingKey(parts)
+	chainingKey, err := p.determineChainingKey(key, parts)
+	if err != nil {
+		return Filter{}, err
+	}
 
 	var value any = parts[1]
-	if operator == In || operator == NotIn {
+	if operator == In || operator == NotIn || operator == HasAnyKey || operator == HasAllKeys {
 		value = strings.Split(parts[1], ",")
 	}
 
-	fieldSplit := strings.Split(field, ".")
-	module := ""
-	fieldKey := field
-	if len(fieldSplit) == 2 {
-		module = fieldSplit[0]
-		fieldKey = fieldSplit[1]
+	module, field, path, err := p.parseFilterKey(key)
+	if err != nil {
+		return Filter{}, err
 	}
 
 	return Filter{
 		Module:                            module,
-		Field:                             FilterField(fieldKey),
+		Field:                             FilterField(field),
+		Path:                              path,
 		Operator:                          operator,
 		Value:                             value,
 		ChainingKey:                       chainingKey,
@@ -213,6 +285,55 @@ func (p *QueryParser) parseFilter(field string, parts []string) (Filter, error)
 	}, nil
 }
 
+// parseFilterKey splits a filter key into its module, field and JSON path.
+// Example: "ledger.amount" is the field amount of module ledger and, with meta registered through WithJSONFields,
+// "meta.customer.id" is the path customer.id in meta.
+func (p *QueryParser) parseFilterKey(key string) (string, string, []string, error) {
+	segments := strings.Split(key, ".")
+
+	module := ""
+	field := key
+	var path []string
+
+	switch {
+	case len(segments) == 1:
+	case p.isJSONField(segments[0]):
+		field = segments[0]
+		path = segments[1:]
+	case len(segments) > 2 && p.isJSONField(segments[1]):
+		module = segments[0]
+		field = segments[1]
+		path = segments[2:]
+	case len(segments) == 2:
+		module = segments[0]
+		field = segments[1]
+	}
+
+	if len(path) > MaxJSONPathDepth {
+		return "", "", nil, oops.
+			Code("invalid_json_path").
+			With("key", key).
+			Errorf("JSON path exceeds %d segments: %s", MaxJSONPathDepth, key)
+	}
+
+	for _, segment := range path {
+		if !IsJSONPathSegment(segment) {
+			return "", "", nil, oops.
+				Code("invalid_json_path").
+				With("key", key).
+				Errorf("invalid JSON path segment %q: %s", segment, key)
+		}
+	}
+
+	return module, field, path, nil
+}
+
+func (p *QueryParser) isJSONField(field string) bool {
+	_, ok := p.jsonFields[field]
+
+	return ok
+}
+
 func (p *QueryParser) determineOperator(op string) FilterOperator {
 	operator := FilterOperator(op)
 	if _, ok := p.operators[operator]; !ok {
@@ -222,12 +343,26 @@ func (p *QueryParser) determineOperator(op string) FilterOperator {
 	return operator
 }
 
-func (p *QueryParser) determineChainingKey(parts []string) FilterChainingKey {
+func (p *QueryParser) determineChainingKey(key string, parts []string) (FilterChainingKey, error) {
 	if len(parts) == 3 {
-		return FilterChainingKey(strings.ToUpper(parts[2]))
+		return p.parseChainingKey(key, parts[2])
 	}
 
-	return defaultChaining
+	return defaultChaining, nil
+}
+
+// parseChainingKey parses a chaining key, which is written into the SQL text and must be AND or OR.
+func (p *QueryParser) parseChainingKey(key, value string) (FilterChainingKey, error) {
+	chainingKey := FilterChainingKey(strings.ToUpper(value))
+	switch chainingKey {
+	case And, Or:
+		return chainingKey, nil
+	default:
+		return "", oops.
+			Code("invalid_chaining_key").
+			With("key", key).
+			Errorf("invalid chaining key %q, expected AND or OR: %s", value, key)
+	}
 }
 
 // parseSelect parses the select parameter which contains comma-separated field names.
@@ -256,6 +391,61 @@ func (p *QueryParser) parseSelect(value string, criteria *Criteria) error {
 	return nil
 }
 
+// parseGroup parses the group parameter which contains comma-separated field names.
+// Example: "group=accountId,currency".
+func (p *QueryParser) parseGroup(value string, criteria *Criteria) {
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			criteria.Groups = append(criteria.Groups, field)
+		}
+	}
+}
+
+// parseAggregations parses the agg parameter which contains comma-separated function:field[:alias] items.
+// Example: "agg=sum:amount,count:*" or "agg=sum:amount:total".
+// When no alias is given it defaults to function_field, or just the function for "*".
+func (p *QueryParser) parseAggregations(value string, criteria *Criteria) error {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) < 2 || parts[1] == "" {
+			return oops.
+				Code("invalid_aggregation").
+				With("aggregation", item).
+				Errorf("invalid aggregation, expected function:field: %s", item)
+		}
+
+		function := AggregateFunction(strings.ToLower(parts[0]))
+		if _, ok := p.aggregateFunctions[function]; !ok {
+			return oops.
+				Code("invalid_aggregate_function").
+				With("aggregation", item).
+				Errorf("invalid aggregate function: %s", parts[0])
+		}
+
+		aggregation := Aggregation{
+			Function: function,
+			Field:    parts[1],
+			Alias:    string(function) + "_" + parts[1],
+		}
+		if parts[1] == "*" {
+			aggregation.Alias = string(function)
+		}
+		if len(parts) == 3 && parts[2] != "" {
+			aggregation.Alias = parts[2]
+		}
+
+		criteria.Aggregations = append(criteria.Aggregations, aggregation)
+	}
+
+	return nil
+}
+
 // ValidateSelectFields validates that all requested select fields are valid domain fields.
 // This should be called by repositories that want to enforce field validation.
 func ValidateSelectFields(selectFields []string, validFields map[string]string) error {
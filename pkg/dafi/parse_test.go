@@ -24,8 +24,17 @@ func TestQueryParser_Parse(t *testing.T) {
 		"isn":       {},
 	}
 
+	defaultAggregateFunctions := map[AggregateFunction]struct{}{
+		"count": {},
+		"sum":   {},
+		"avg":   {},
+		"min":   {},
+		"max":   {},
+	}
+
 	type fields struct {
-		operators map[FilterOperator]struct{}
+		operators          map[FilterOperator]struct{}
+		aggregateFunctions map[AggregateFunction]struct{}
 	}
 	type args struct {
 		values url.Values
@@ -222,6 +231,67 @@ func TestQueryParser_Parse(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "group and aggregations",
+			fields: fields{operators: defaultOperators, aggregateFunctions: defaultAggregateFunctions},
+			args: args{values: url.Values{
+				"group":    []string{"accountId, currency"},
+				"agg":      []string{"sum:amount,count:*", "max:amount:largest"},
+				"currency": []string{"eq:USD"},
+			}},
+			want: Criteria{
+				Groups: []string{"accountId", "currency"},
+				Aggregations: Aggregations{
+					{Function: Sum, Field: "amount", Alias: "sum_amount"},
+					{Function: Count, Field: "*", Alias: "count"},
+					{Function: Max, Field: "amount", Alias: "largest"},
+				},
+				Filters: Filters{
+					{Field: "currency", Operator: "eq", Value: "USD", ChainingKey: And},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "invalid aggregate function",
+			fields: fields{operators: defaultOperators, aggregateFunctions: defaultAggregateFunctions},
+			args: args{values: url.Values{
+				"agg": []string{"median:amount"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "invalid sort type",
+			fields: fields{operators: defaultOperators, aggregateFunctions: defaultAggregateFunctions},
+			args: args{values: url.Values{
+				"name": []string{"sort:asc;drop"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "invalid chaining key",
+			fields: fields{operators: defaultOperators, aggregateFunctions: defaultAggregateFunctions},
+			args: args{values: url.Values{
+				"amount": []string{"eq:5:OR 1=1 OR"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "invalid override chaining key",
+			fields: fields{operators: defaultOperators, aggregateFunctions: defaultAggregateFunctions},
+			args: args{values: url.Values{
+				"amount": []string{"or:eq:5:; DELETE FROM t; --"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "aggregation without field",
+			fields: fields{operators: defaultOperators, aggregateFunctions: defaultAggregateFunctions},
+			args: args{values: url.Values{
+				"agg": []string{"sum"},
+			}},
+			wantErr: true,
+		},
 		// {
 		// 	name:   "filters by module",
 		// 	fields: fields{operators: defaultOperators},
@@ -247,7 +317,8 @@ func TestQueryParser_Parse(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &QueryParser{
-				operators: tt.fields.operators,
+				operators:          tt.fields.operators,
+				aggregateFunctions: tt.fields.aggregateFunctions,
 			}
 
 			got, err := p.Parse(tt.args.values)
@@ -262,6 +333,110 @@ func TestQueryParser_Parse(t *testing.T) {
 			assert.Equal(t, tt.want.Pagination, got.Pagination)
 			assert.Equal(t, tt.want.SelectColumns, got.SelectColumns)
 			assert.Equal(t, tt.want.FiltersByModule, got.FiltersByModule)
+			assert.Equal(t, tt.want.Groups, got.Groups)
+			assert.Equal(t, tt.want.Aggregations, got.Aggregations)
+		})
+	}
+}
+
+func TestQueryParser_ParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  url.Values
+		want    Criteria
+		wantErr bool
+	}{
+		{
+			name:   "path equality",
+			values: url.Values{"meta.customer.id": []string{"eq:42"}},
+			want: Criteria{Filters: Filters{
+				{Field: "meta", Path: []string{"customer", "id"}, Operator: Equal, Value: "42", ChainingKey: And},
+			}},
+		},
+		{
+			name:   "path in registered json field",
+			values: url.Values{"meta.tier": []string{"in:gold,platinum"}},
+			want: Criteria{Filters: Filters{
+				{Field: "meta", Path: []string{"tier"}, Operator: In, Value: []string{"gold", "platinum"}, ChainingKey: And},
+			}},
+		},
+		{
+			name:   "path in module json field",
+			values: url.Values{"ledger.meta.tags.0": []string{"eq:vip"}},
+			want: Criteria{FiltersByModule: map[string]Filters{
+				"ledger": {
+					{Module: "ledger", Field: "meta", Path: []string{"tags", "0"}, Operator: Equal, Value: "vip", ChainingKey: And},
+				},
+			}},
+		},
+		{
+			name:   "module field is not a path",
+			values: url.Values{"category.id": []string{"eq:123"}},
+			want: Criteria{FiltersByModule: map[string]Filters{
+				"category": {
+					{Module: "category", Field: "id", Operator: Equal, Value: "123", ChainingKey: And},
+				},
+			}},
+		},
+		{
+			name:   "containment keeps colons in the document",
+			values: url.Values{"meta": []string{`jcontains:{"tier":"gold","limits":{"daily":100}}`}},
+			want: Criteria{Filters: Filters{
+				{Field: "meta", Operator: JSONContains, Value: `{"tier":"gold","limits":{"daily":100}}`, ChainingKey: And},
+			}},
+		},
+		{
+			name:   "jsonpath predicate with chaining override",
+			values: url.Values{"meta": []string{`or:jsonpath:$.settledAt > "2024-01-01T00:00:00Z"`}},
+			want: Criteria{Filters: Filters{
+				{Field: "meta", Operator: JSONPathMatch, Value: `$.settledAt > "2024-01-01T00:00:00Z"`, ChainingKey: And, OverridePreviousFilterChainingKey: Or},
+			}},
+		},
+		{
+			name: "key existence",
+			values: url.Values{
+				"meta":     []string{"haskey:refund"},
+				"meta.tax": []string{"hasall:rate,region"},
+			},
+			want: Criteria{Filters: Filters{
+				{Field: "meta", Operator: HasKey, Value: "refund", ChainingKey: And},
+				{Field: "meta", Path: []string{"tax"}, Operator: HasAllKeys, Value: []string{"rate", "region"}, ChainingKey: And},
+			}},
+		},
+		{
+			name:   "unregistered field is not a path",
+			values: url.Values{"ledger.entries.amount": []string{"gt:100"}},
+			want: Criteria{Filters: Filters{
+				{Field: "ledger.entries.amount", Operator: Greater, Value: "100", ChainingKey: And},
+			}},
+		},
+		{
+			name:    "error invalid path segment",
+			values:  url.Values{"meta.customer id.name": []string{"eq:42"}},
+			wantErr: true,
+		},
+		{
+			name:    "error empty path segment",
+			values:  url.Values{"meta..id": []string{"eq:42"}},
+			wantErr: true,
+		},
+		{
+			name:    "error path too deep",
+			values:  url.Values{"meta.a.b.c.d.e.f.g.h.i.j.k.l.m.n.o.p.q": []string{"eq:42"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewQueryParser().WithJSONFields("meta").Parse(tt.values)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			assert.ElementsMatch(t, tt.want.Filters, got.Filters)
+			assert.Equal(t, tt.want.FiltersByModule, got.FiltersByModule)
 		})
 	}
 }
@@ -17,8 +17,8 @@ type SortBy string
 
 // Sort represents a sort instruction.
 type Sort struct {
-	Field SortBy
-	Type  SortType
+	Field SortBy   `json:"field"`
+	Type  SortType `json:"type,omitempty"`
 }
 
 // Sorts represents a collection of sort instructions.
@@ -4,8 +4,10 @@ package database
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"backend.atomicledger.com/pkg/logger"
+	"backend.atomicledger.com/pkg/sqlcraft"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -15,6 +17,19 @@ import (
 // Ensure DatabaseInterface is implemented by Database
 var _ DatabaseInterface = (*Database)(nil)
 
+// Ensure the pgx pool supports transactions and COPY.
+var (
+	_ TxPool   = (*pgxpool.Pool)(nil)
+	_ CopyPool = (*pgxpool.Pool)(nil)
+)
+
+var (
+	// ErrTxUnsupported is returned when a chunked insert needs a transaction and the pool does not implement TxPool.
+	ErrTxUnsupported = errors.New("database pool does not support transactions")
+	// ErrCopyUnsupported is returned by CopyFrom when the pool does not implement CopyPool.
+	ErrCopyUnsupported = errors.New("database pool does not support copy")
+)
+
 const (
 	sqlPreviewMaxLen = 100
 )
@@ -134,6 +149,122 @@ func (db *Database) Exec(ctx context.Context, sql string, args ...any) (pgconn.C
 	return tag, nil
 }
 
+// Insert executes the INSERT query split into statements within the bind parameter limit.
+// A query that needs more than one statement runs in a single transaction, so either every row is inserted or none;
+// the pool must implement TxPool. Queries with an ON CONFLICT clause are never split, see sqlcraft.ErrChunkedConflict.
+// RETURNING rows are discarded, the number of inserted rows is returned.
+func (db *Database) Insert(ctx context.Context, query sqlcraft.InsertQuery) (int64, error) {
+	chunks, err := query.ToSQLChunks()
+	if err != nil {
+		return 0, oops.
+			Code("db_insert_build_failed").
+			With("operation", "insert").
+			Wrapf(err, "failed to build insert query")
+	}
+
+	if len(chunks) == 1 {
+		tag, err := db.Exec(ctx, chunks[0].SQL, chunks[0].Args...)
+		if err != nil {
+			return 0, err
+		}
+
+		return tag.RowsAffected(), nil
+	}
+
+	txPool, ok := db.Pool.(TxPool)
+	if !ok {
+		return 0, oops.
+			Code("db_begin_failed").
+			With("operation", "insert").
+			Wrapf(ErrTxUnsupported, "failed to begin insert transaction")
+	}
+
+	tx, err := txPool.Begin(ctx)
+	if err != nil {
+		db.logger.Error("begin transaction failed", "error", err)
+		return 0, oops.
+			Code("db_begin_failed").
+			With("operation", "insert").
+			Wrapf(err, "failed to begin insert transaction")
+	}
+
+	defer func() {
+		// Rollback is a no-op once the transaction is committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			db.logger.Error("rollback failed", "error", err)
+		}
+	}()
+
+	var rowsAffected int64
+	for index, chunk := range chunks {
+		tag, err := tx.Exec(ctx, chunk.SQL, chunk.Args...)
+		if err != nil {
+			db.logger.Error("chunked insert failed",
+				"error", err,
+				"chunk", index,
+				"chunks", len(chunks),
+				"sql_preview", truncateSQL(chunk.SQL),
+			)
+			return 0, oops.
+				Code("db_insert_failed").
+				With("operation", "insert").
+				With("chunk", index).
+				With("sql_preview", truncateSQL(chunk.SQL)).
+				Wrapf(err, "database chunked insert failed")
+		}
+		rowsAffected += tag.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		db.logger.Error("commit failed", "error", err)
+		return 0, oops.
+			Code("db_commit_failed").
+			With("operation", "insert").
+			Wrapf(err, "failed to commit insert transaction")
+	}
+
+	db.logger.Debug("chunked insert completed",
+		"rows_affected", rowsAffected,
+		"chunks", len(chunks),
+	)
+
+	return rowsAffected, nil
+}
+
+// CopyFrom bulk loads rows into the table using the COPY protocol, it is the fastest path for large imports.
+// The table may be schema qualified. COPY has no conflict handling, a single failing row aborts the whole copy.
+// The pool must implement CopyPool.
+func (db *Database) CopyFrom(ctx context.Context, table string, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	copyPool, ok := db.Pool.(CopyPool)
+	if !ok {
+		return 0, oops.
+			Code("db_copy_failed").
+			With("operation", "copy_from").
+			With("table", table).
+			Wrapf(ErrCopyUnsupported, "database copy failed")
+	}
+
+	count, err := copyPool.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, rows)
+	if err != nil {
+		db.logger.Error("copy operation failed",
+			"error", err,
+			"table", table,
+		)
+		return 0, oops.
+			Code("db_copy_failed").
+			With("operation", "copy_from").
+			With("table", table).
+			Wrapf(err, "database copy failed")
+	}
+
+	db.logger.Debug("copy operation completed",
+		"rows_copied", count,
+		"table", table,
+	)
+
+	return count, nil
+}
+
 // HealthCheck performs a health check on the database connection.
 func (db *Database) HealthCheck(ctx context.Context) error {
 	if db.Pool == nil {
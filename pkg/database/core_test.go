@@ -2,12 +2,89 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
 	"testing"
 
 	"backend.atomicledger.com/pkg/logger"
+	"backend.atomicledger.com/pkg/sqlcraft"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 )
 
+var errExecFailed = errors.New("exec failed")
+
+// fakePool records the statements executed on the pool and in transactions.
+type fakePool struct {
+	PoolInterface
+
+	execs      []string
+	failOnExec int
+	committed  bool
+	rolledBack bool
+	copied     pgx.Identifier
+}
+
+func (p *fakePool) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	p.execs = append(p.execs, sql)
+	if len(p.execs) == p.failOnExec {
+		return pgconn.CommandTag{}, errExecFailed
+	}
+
+	return pgconn.NewCommandTag(fmt.Sprintf("INSERT 0 %d", len(args))), nil
+}
+
+func (p *fakePool) Begin(context.Context) (pgx.Tx, error) {
+	return &fakeTx{pool: p}, nil
+}
+
+func (p *fakePool) CopyFrom(_ context.Context, tableName pgx.Identifier, _ []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	p.copied = tableName
+
+	var count int64
+	for rowSrc.Next() {
+		count++
+	}
+
+	return count, nil
+}
+
+// plainPool is a pool without transactions or COPY support.
+type plainPool struct {
+	PoolInterface
+}
+
+func (p *plainPool) Exec(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.NewCommandTag(fmt.Sprintf("INSERT 0 %d", len(args))), nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+
+	pool *fakePool
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return tx.pool.Exec(ctx, sql, args...)
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.pool.committed = true
+
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if tx.pool.committed {
+		return pgx.ErrTxClosed
+	}
+	tx.pool.rolledBack = true
+
+	return nil
+}
+
 func TestDatabase_NewConnection(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -119,3 +196,173 @@ func TestDatabase_Shutdown(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestDatabase_Insert(t *testing.T) {
+	maxParameters := sqlcraft.Postgres.MaxParameters()
+	rows := func(count int) []any {
+		return make([]any, count)
+	}
+
+	tests := []struct {
+		name           string
+		values         []any
+		failOnExec     int
+		wantRows       int64
+		wantExecs      int
+		wantCommitted  bool
+		wantRolledBack bool
+		conflict       bool
+		wantErr        error
+	}{
+		{
+			name:      "single statement without transaction",
+			values:    rows(10),
+			wantRows:  10,
+			wantExecs: 1,
+		},
+		{
+			name:          "chunks in one transaction",
+			values:        rows(maxParameters*2 + 1),
+			wantRows:      int64(maxParameters*2 + 1),
+			wantExecs:     3,
+			wantCommitted: true,
+		},
+		{
+			name:           "failed chunk rolls back",
+			values:         rows(maxParameters * 3),
+			failOnExec:     2,
+			wantExecs:      2,
+			wantRolledBack: true,
+			wantErr:        errExecFailed,
+		},
+		{
+			name:      "single statement upsert",
+			values:    rows(10),
+			conflict:  true,
+			wantRows:  10,
+			wantExecs: 1,
+		},
+		{
+			name:     "error upsert split in chunks",
+			values:   rows(maxParameters + 1),
+			conflict: true,
+			wantErr:  sqlcraft.ErrChunkedConflict,
+		},
+		{
+			name:    "invalid query",
+			values:  nil,
+			wantErr: sqlcraft.ErrEmptyValues,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &fakePool{failOnExec: tt.failOnExec}
+			db := &Database{
+				Pool:   pool,
+				logger: logger.NewNoop(),
+			}
+
+			query := sqlcraft.InsertInto("postings").WithColumns("amount").WithValues(tt.values...)
+			if tt.conflict {
+				query = query.OnConflict("amount").DoNothing()
+			}
+
+			got, err := db.Insert(context.Background(), query)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRows, got)
+			assert.Len(t, pool.execs, tt.wantExecs)
+			assert.Equal(t, tt.wantCommitted, pool.committed)
+			assert.Equal(t, tt.wantRolledBack, pool.rolledBack)
+		})
+	}
+}
+
+func TestDatabase_Insert_WithoutTransactions(t *testing.T) {
+	db := &Database{
+		Pool:   &plainPool{},
+		logger: logger.NewNoop(),
+	}
+	query := sqlcraft.InsertInto("postings").WithColumns("amount")
+
+	got, err := db.Insert(context.Background(), query.WithValues(make([]any, 10)...))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), got)
+
+	_, err = db.Insert(context.Background(), query.WithValues(make([]any, sqlcraft.Postgres.MaxParameters()+1)...))
+	assert.ErrorIs(t, err, ErrTxUnsupported)
+}
+
+func TestDatabase_CopyFrom(t *testing.T) {
+	pool := &fakePool{}
+	db := &Database{
+		Pool:   pool,
+		logger: logger.NewNoop(),
+	}
+
+	count, err := db.CopyFrom(context.Background(), "ledger.postings", []string{"account_id", "amount"}, pgx.CopyFromRows([][]any{{1, 100}, {2, -100}}))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, pgx.Identifier{"ledger", "postings"}, pool.copied)
+
+	db.Pool = &plainPool{}
+	_, err = db.CopyFrom(context.Background(), "ledger.postings", []string{"account_id"}, pgx.CopyFromRows([][]any{{1}}))
+	assert.ErrorIs(t, err, ErrCopyUnsupported)
+}
+
+// BenchmarkDatabase_BulkInsert compares the chunked INSERT path with COPY.
+// It needs a Postgres database, set BENCHMARK_DATABASE_URL to run it.
+func BenchmarkDatabase_BulkInsert(b *testing.B) {
+	connString := os.Getenv("BENCHMARK_DATABASE_URL")
+	if connString == "" {
+		b.Skip("BENCHMARK_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	db, err := NewConnection(ctx, connString, logger.NewNoop())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(db.Close)
+
+	const table = "benchmark_postings"
+	columns := []string{"account_id", "amount", "currency"}
+
+	if _, err := db.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+table+" (account_id BIGINT NOT NULL, amount BIGINT NOT NULL, currency TEXT NOT NULL)"); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		if _, err := db.Exec(ctx, "DROP TABLE "+table); err != nil {
+			b.Error(err)
+		}
+	})
+
+	for _, size := range []int{1_000, 50_000} {
+		rows := make([][]any, size)
+		values := make([]any, 0, size*len(columns))
+		for i := range rows {
+			rows[i] = []any{int64(i), int64(i * 100), "USD"}
+			values = append(values, rows[i]...)
+		}
+
+		b.Run(fmt.Sprintf("insert/%d", size), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := db.Insert(ctx, sqlcraft.InsertInto(table).WithColumns(columns...).WithValues(values...)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("copy/%d", size), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := db.CopyFrom(ctx, table, columns, pgx.CopyFromRows(rows)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
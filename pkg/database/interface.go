@@ -17,6 +17,17 @@ type PoolInterface interface {
 	Close()
 }
 
+// TxPool is implemented by pools that can start transactions, e.g. *pgxpool.Pool.
+// It is kept out of PoolInterface so existing implementations and mocks do not need it.
+type TxPool interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// CopyPool is implemented by pools that support the COPY protocol, e.g. *pgxpool.Pool.
+type CopyPool interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
 // DatabaseInterface defines the interface for database operations
 type DatabaseInterface interface {
 	GetPool() PoolInterface
@@ -0,0 +1,127 @@
+// Package money provides allocation of amounts expressed in int64 minor units, e.g. cents.
+package money
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// basisPointsTotal is 100% expressed in basis points.
+const basisPointsTotal = 10_000
+
+var (
+	// ErrNoParts is returned when an amount is allocated into no parts.
+	ErrNoParts = errors.New("no allocation parts")
+	// ErrNegativeRatio is returned when an allocation ratio is negative.
+	ErrNegativeRatio = errors.New("negative allocation ratio")
+	// ErrZeroRatios is returned when all allocation ratios are zero.
+	ErrZeroRatios = errors.New("allocation ratios sum to zero")
+	// ErrInvalidPercentages is returned when the percentages do not sum to 100%.
+	ErrInvalidPercentages = errors.New("allocation percentages must sum to 100%")
+)
+
+// SplitEven splits total into parts amounts that differ by at most one minor unit.
+// The remainder goes to the first parts, e.g. 100 split in 3 is 34, 33, 33.
+func SplitEven(total int64, parts int) ([]int64, error) {
+	if parts <= 0 {
+		return nil, ErrNoParts
+	}
+
+	ratios := make([]int64, parts)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+
+	return AllocateRatios(total, ratios...)
+}
+
+// AllocatePercentages splits total by percentages expressed in basis points (1% is 100),
+// which must sum to 100%. Remainders are distributed like in AllocateRatios.
+func AllocatePercentages(total int64, basisPoints ...int64) ([]int64, error) {
+	if len(basisPoints) == 0 {
+		return nil, ErrNoParts
+	}
+
+	var sum int64
+	for _, points := range basisPoints {
+		if points < 0 {
+			return nil, ErrNegativeRatio
+		}
+
+		sum += points
+		if sum > basisPointsTotal {
+			return nil, ErrInvalidPercentages
+		}
+	}
+
+	if sum != basisPointsTotal {
+		return nil, ErrInvalidPercentages
+	}
+
+	return AllocateRatios(total, basisPoints...)
+}
+
+// AllocateRatios splits total pro-rata by the ratios so the parts always sum to total.
+//
+// Every part gets its exact share rounded towards zero. The minor units left over go one each to the
+// parts with the largest rounding remainder, ties going to the earlier part, so the result is
+// deterministic and a part with a zero ratio always gets zero. Negative totals are allocated like
+// their absolute value and negated.
+func AllocateRatios(total int64, ratios ...int64) ([]int64, error) {
+	if len(ratios) == 0 {
+		return nil, ErrNoParts
+	}
+
+	ratioSum := new(big.Int)
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, ErrNegativeRatio
+		}
+
+		ratioSum.Add(ratioSum, big.NewInt(ratio))
+	}
+
+	if ratioSum.Sign() == 0 {
+		return nil, ErrZeroRatios
+	}
+
+	// big.Int avoids overflowing total * ratio and negating math.MinInt64.
+	absTotal := new(big.Int).Abs(big.NewInt(total))
+
+	shares := make([]*big.Int, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	allocated := new(big.Int)
+
+	for i, ratio := range ratios {
+		product := new(big.Int).Mul(absTotal, big.NewInt(ratio))
+		shares[i], remainders[i] = new(big.Int).QuoRem(product, ratioSum, new(big.Int))
+		allocated.Add(allocated, shares[i])
+	}
+
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+
+	// The leftover is always smaller than the number of parts with a non-zero remainder.
+	leftover := new(big.Int).Sub(absTotal, allocated).Int64()
+	for _, index := range order[:leftover] {
+		shares[index].Add(shares[index], big.NewInt(1))
+	}
+
+	parts := make([]int64, len(ratios))
+	for i, share := range shares {
+		if total < 0 {
+			share.Neg(share)
+		}
+
+		parts[i] = share.Int64()
+	}
+
+	return parts, nil
+}
@@ -0,0 +1,163 @@
+package money
+
+import (
+	"math"
+	"math/big"
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitEven(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		parts   int
+		want    []int64
+		wantErr error
+	}{
+		{name: "exact", total: 90, parts: 3, want: []int64{30, 30, 30}},
+		{name: "remainder to the first parts", total: 10_000, parts: 3, want: []int64{3334, 3333, 3333}},
+		{name: "less than one unit per part", total: 2, parts: 4, want: []int64{1, 1, 0, 0}},
+		{name: "negative total", total: -100, parts: 3, want: []int64{-34, -33, -33}},
+		{name: "zero total", total: 0, parts: 2, want: []int64{0, 0}},
+		{name: "error no parts", total: 100, parts: 0, wantErr: ErrNoParts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitEven(tt.total, tt.parts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAllocateRatios(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		ratios  []int64
+		want    []int64
+		wantErr error
+	}{
+		{name: "pro-rata", total: 100, ratios: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "largest remainder wins", total: 100, ratios: []int64{1, 2, 3}, want: []int64{17, 33, 50}},
+		{name: "zero ratio gets nothing", total: 5, ratios: []int64{0, 1, 1}, want: []int64{0, 3, 2}},
+		{name: "no overflow", total: math.MaxInt64, ratios: []int64{math.MaxInt64, math.MaxInt64}, want: []int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+		{name: "min int64", total: math.MinInt64, ratios: []int64{1}, want: []int64{math.MinInt64}},
+		{name: "error negative ratio", total: 100, ratios: []int64{1, -1}, wantErr: ErrNegativeRatio},
+		{name: "error zero ratios", total: 100, ratios: []int64{0, 0}, wantErr: ErrZeroRatios},
+		{name: "error no ratios", total: 100, wantErr: ErrNoParts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AllocateRatios(tt.total, tt.ratios...)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAllocatePercentages(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int64
+		basisPoints []int64
+		want        []int64
+		wantErr     error
+	}{
+		{name: "cost centers", total: 100_00, basisPoints: []int64{5000, 3333, 1667}, want: []int64{50_00, 33_33, 16_67}},
+		{name: "remainder", total: 1, basisPoints: []int64{2500, 7500}, want: []int64{0, 1}},
+		{name: "error under 100%", total: 100, basisPoints: []int64{5000, 4000}, wantErr: ErrInvalidPercentages},
+		{name: "error over 100%", total: 100, basisPoints: []int64{math.MaxInt64, 1}, wantErr: ErrInvalidPercentages},
+		{name: "error negative", total: 100, basisPoints: []int64{-1000, 11000}, wantErr: ErrNegativeRatio},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AllocatePercentages(tt.total, tt.basisPoints...)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitEven_Properties(t *testing.T) {
+	property := func(total int64, parts uint8) bool {
+		count := int(parts)%50 + 1
+
+		got, err := SplitEven(total, count)
+		if err != nil || len(got) != count {
+			return false
+		}
+
+		// Parts sum to the total and differ by at most one minor unit.
+		if slices.Max(got)-slices.Min(got) > 1 {
+			return false
+		}
+
+		return sum(got).Cmp(big.NewInt(total)) == 0
+	}
+
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func TestAllocateRatios_Properties(t *testing.T) {
+	property := func(total int64, ratios []uint32) bool {
+		values := make([]int64, 0, len(ratios))
+		var ratioSum int64
+		for _, ratio := range ratios {
+			values = append(values, int64(ratio))
+			ratioSum += int64(ratio)
+		}
+
+		got, err := AllocateRatios(total, values...)
+		if len(values) == 0 || ratioSum == 0 {
+			return err != nil
+		}
+		if err != nil {
+			return false
+		}
+
+		// Every part is within one minor unit of its exact share and zero ratios get nothing.
+		for i, part := range got {
+			exact := new(big.Rat).SetFrac(new(big.Int).Mul(big.NewInt(total), big.NewInt(values[i])), big.NewInt(ratioSum))
+			diff := new(big.Rat).Sub(new(big.Rat).SetInt64(part), exact)
+			if diff.Abs(diff).Cmp(big.NewRat(1, 1)) >= 0 {
+				return false
+			}
+
+			if values[i] == 0 && part != 0 {
+				return false
+			}
+		}
+
+		again, err := AllocateRatios(total, values...)
+
+		return err == nil && assert.ObjectsAreEqual(got, again) && sum(got).Cmp(big.NewInt(total)) == 0
+	}
+
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func sum(parts []int64) *big.Int {
+	total := new(big.Int)
+	for _, part := range parts {
+		total.Add(total, big.NewInt(part))
+	}
+
+	return total
+}
@@ -0,0 +1,244 @@
+// Package nlquery translates natural-language questions into validated dafi criteria.
+package nlquery
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/samber/oops"
+)
+
+const defaultMaxLimit = 100
+
+var (
+	// ErrEmptyQuestion is returned when the question is blank.
+	ErrEmptyQuestion = errors.New("empty question")
+	// ErrEmptyOutput is returned when the model produces no structured output.
+	ErrEmptyOutput = errors.New("empty model output")
+	// ErrInvalidField is returned when the model references a field that is not allowed.
+	ErrInvalidField = errors.New("invalid field name")
+	// ErrInvalidOperator is returned when the model produces an unsupported operator.
+	ErrInvalidOperator = errors.New("invalid operator")
+	// ErrInvalidChainingKey is returned when the model produces a chaining key other than AND/OR.
+	ErrInvalidChainingKey = errors.New("invalid chaining key")
+	// ErrInvalidSortType is returned when the model produces a sort direction other than ASC/DESC.
+	ErrInvalidSortType = errors.New("invalid sort type")
+)
+
+// Field describes a domain field that questions may filter or sort by.
+type Field struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Filter is a single filter produced by a Model.
+type Filter struct {
+	Field       string `json:"field"`
+	Operator    string `json:"operator"`
+	Value       string `json:"value,omitempty"`
+	ChainingKey string `json:"chainingKey,omitempty"`
+}
+
+// Sort is a single sort instruction produced by a Model.
+type Sort struct {
+	Field string `json:"field"`
+	Type  string `json:"type,omitempty"`
+}
+
+// Query is the structured output a Model produces for a question.
+// It is untrusted until it has been validated by a Translator.
+type Query struct {
+	Filters []Filter `json:"filters,omitempty"`
+	Sorts   []Sort   `json:"sorts,omitempty"`
+	Limit   uint     `json:"limit,omitempty"`
+}
+
+// Model turns a question into a structured Query.
+// Implementations must only reference the given fields, but their output is validated anyway.
+type Model interface {
+	Generate(ctx context.Context, question string, fields []Field) (Query, error)
+}
+
+// Translator turns questions into dafi.Criteria using a Model and validates the result
+// against the allowed fields before it reaches the query path.
+type Translator struct {
+	model     Model
+	fields    []Field
+	allowed   map[string]struct{}
+	operators map[dafi.FilterOperator]struct{}
+	maxLimit  uint
+}
+
+// NewTranslator creates a new Translator that only accepts the given fields.
+func NewTranslator(model Model, fields ...Field) *Translator {
+	allowed := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		allowed[field.Name] = struct{}{}
+	}
+
+	return &Translator{
+		model:   model,
+		fields:  fields,
+		allowed: allowed,
+		// dafi.Default is left out on purpose: it expects a raw sub-query as value.
+		operators: map[dafi.FilterOperator]struct{}{
+			dafi.Equal:          {},
+			dafi.NotEqual:       {},
+			dafi.Greater:        {},
+			dafi.GreaterOrEqual: {},
+			dafi.Less:           {},
+			dafi.LessOrEqual:    {},
+			dafi.In:             {},
+			dafi.NotIn:          {},
+			dafi.Contains:       {},
+			dafi.NotContains:    {},
+			dafi.IsNull:         {},
+			dafi.IsNotNull:      {},
+		},
+		maxLimit: defaultMaxLimit,
+	}
+}
+
+// WithMaxLimit caps the page size the model may request.
+func (t *Translator) WithMaxLimit(limit uint) *Translator {
+	t.maxLimit = limit
+
+	return t
+}
+
+// Fields returns the fields the Translator accepts.
+func (t *Translator) Fields() []Field {
+	return t.fields
+}
+
+// Translate asks the model to interpret the question and returns the validated criteria.
+// The criteria can be passed to repositories exactly like criteria parsed by dafi.QueryParser.
+func (t *Translator) Translate(ctx context.Context, question string) (dafi.Criteria, error) {
+	query, err := t.generate(ctx, question)
+	if err != nil {
+		return dafi.Criteria{}, err
+	}
+
+	return t.ToCriteria(query)
+}
+
+func (t *Translator) generate(ctx context.Context, question string) (Query, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return Query{}, oops.Code("nlquery_empty_question").Wrap(ErrEmptyQuestion)
+	}
+
+	query, err := t.model.Generate(ctx, question, t.fields)
+	if err != nil {
+		return Query{}, oops.
+			Code("nlquery_generation_failed").
+			Wrapf(err, "failed to translate question")
+	}
+
+	return query, nil
+}
+
+// ToCriteria validates a Query and converts it into dafi.Criteria.
+func (t *Translator) ToCriteria(query Query) (dafi.Criteria, error) {
+	criteria := dafi.Criteria{}
+
+	for _, f := range query.Filters {
+		filter, err := t.toFilter(f)
+		if err != nil {
+			return dafi.Criteria{}, err
+		}
+
+		criteria.Filters = append(criteria.Filters, filter)
+	}
+
+	for _, s := range query.Sorts {
+		sort, err := t.toSort(s)
+		if err != nil {
+			return dafi.Criteria{}, err
+		}
+
+		criteria.Sorts = append(criteria.Sorts, sort)
+	}
+
+	if query.Limit > 0 {
+		criteria.Pagination.PageSize = min(query.Limit, t.maxLimit)
+	}
+
+	return criteria, nil
+}
+
+func (t *Translator) toFilter(f Filter) (dafi.Filter, error) {
+	if _, ok := t.allowed[f.Field]; !ok {
+		return dafi.Filter{}, oops.
+			Code("nlquery_invalid_field").
+			With("field", f.Field).
+			Wrap(ErrInvalidField)
+	}
+
+	operator := dafi.FilterOperator(strings.ToLower(f.Operator))
+	if operator == "" {
+		operator = dafi.Equal
+	}
+	if _, ok := t.operators[operator]; !ok {
+		return dafi.Filter{}, oops.
+			Code("nlquery_invalid_operator").
+			With("field", f.Field).
+			With("operator", f.Operator).
+			Wrap(ErrInvalidOperator)
+	}
+
+	chainingKey := dafi.FilterChainingKey(strings.ToUpper(f.ChainingKey))
+	switch chainingKey {
+	case "":
+		chainingKey = dafi.And
+	case dafi.And, dafi.Or:
+	default:
+		return dafi.Filter{}, oops.
+			Code("nlquery_invalid_chaining_key").
+			With("field", f.Field).
+			With("chaining_key", f.ChainingKey).
+			Wrap(ErrInvalidChainingKey)
+	}
+
+	var value any = f.Value
+	switch operator {
+	case dafi.In, dafi.NotIn:
+		value = strings.Split(f.Value, ",")
+	case dafi.IsNull, dafi.IsNotNull:
+		value = nil
+	}
+
+	return dafi.Filter{
+		Field:       dafi.FilterField(f.Field),
+		Operator:    operator,
+		Value:       value,
+		ChainingKey: chainingKey,
+	}, nil
+}
+
+func (t *Translator) toSort(s Sort) (dafi.Sort, error) {
+	if _, ok := t.allowed[s.Field]; !ok {
+		return dafi.Sort{}, oops.
+			Code("nlquery_invalid_field").
+			With("field", s.Field).
+			Wrap(ErrInvalidField)
+	}
+
+	sortType := dafi.SortType(strings.ToUpper(s.Type))
+	switch sortType {
+	case dafi.Asc, dafi.Desc, dafi.None:
+	default:
+		return dafi.Sort{}, oops.
+			Code("nlquery_invalid_sort_type").
+			With("field", s.Field).
+			With("sort_type", s.Type).
+			Wrap(ErrInvalidSortType)
+	}
+
+	return dafi.Sort{
+		Field: dafi.SortBy(s.Field),
+		Type:  sortType,
+	}, nil
+}
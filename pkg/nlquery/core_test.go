@@ -0,0 +1,204 @@
+package nlquery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeModel returns a fixed query for every question.
+type fakeModel struct {
+	query Query
+	err   error
+}
+
+func (m fakeModel) Generate(_ context.Context, _ string, _ []Field) (Query, error) {
+	return m.query, m.err
+}
+
+var testFields = []Field{
+	{Name: "amount", Description: "posting amount"},
+	{Name: "postedAt", Description: "posting date"},
+	{Name: "tag", Description: "posting tag"},
+}
+
+func TestTranslator_Translate(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		query    Query
+		modelErr error
+		want     dafi.Criteria
+		wantErr  error
+	}{
+		{
+			name:     "expenses over 500 in march tagged marketing",
+			question: "expenses over 500 in March tagged marketing",
+			query: Query{
+				Filters: []Filter{
+					{Field: "amount", Operator: "gt", Value: "500"},
+					{Field: "postedAt", Operator: "gte", Value: "2026-03-01"},
+					{Field: "postedAt", Operator: "lt", Value: "2026-04-01"},
+					{Field: "tag", Operator: "eq", Value: "marketing"},
+				},
+				Sorts: []Sort{{Field: "amount", Type: "desc"}},
+			},
+			want: dafi.Criteria{
+				Filters: dafi.Filters{
+					{Field: "amount", Operator: dafi.Greater, Value: "500", ChainingKey: dafi.And},
+					{Field: "postedAt", Operator: dafi.GreaterOrEqual, Value: "2026-03-01", ChainingKey: dafi.And},
+					{Field: "postedAt", Operator: dafi.Less, Value: "2026-04-01", ChainingKey: dafi.And},
+					{Field: "tag", Operator: dafi.Equal, Value: "marketing", ChainingKey: dafi.And},
+				},
+				Sorts: dafi.Sorts{{Field: "amount", Type: dafi.Desc}},
+			},
+		},
+		{
+			name:     "in operator and limit capped",
+			question: "last 500 postings tagged travel or meals",
+			query: Query{
+				Filters: []Filter{{Field: "tag", Operator: "IN", Value: "travel,meals", ChainingKey: "or"}},
+				Limit:   500,
+			},
+			want: dafi.Criteria{
+				Filters:    dafi.Filters{{Field: "tag", Operator: dafi.In, Value: []string{"travel", "meals"}, ChainingKey: dafi.Or}},
+				Pagination: dafi.Pagination{PageSize: defaultMaxLimit},
+			},
+		},
+		{
+			name:     "empty question",
+			question: "  ",
+			wantErr:  ErrEmptyQuestion,
+		},
+		{
+			name:     "unknown field",
+			question: "postings by password",
+			query:    Query{Filters: []Filter{{Field: "password", Operator: "eq", Value: "x"}}},
+			wantErr:  ErrInvalidField,
+		},
+		{
+			name:     "sub-query operator is rejected",
+			question: "anything",
+			query:    Query{Filters: []Filter{{Field: "amount", Operator: "default", Value: "1; DROP TABLE postings"}}},
+			wantErr:  ErrInvalidOperator,
+		},
+		{
+			name:     "invalid chaining key",
+			question: "anything",
+			query:    Query{Filters: []Filter{{Field: "amount", Operator: "eq", Value: "1", ChainingKey: "XOR"}}},
+			wantErr:  ErrInvalidChainingKey,
+		},
+		{
+			name:     "unknown sort field",
+			question: "anything",
+			query:    Query{Sorts: []Sort{{Field: "id; --", Type: "asc"}}},
+			wantErr:  ErrInvalidField,
+		},
+		{
+			name:     "invalid sort type",
+			question: "anything",
+			query:    Query{Sorts: []Sort{{Field: "amount", Type: "sideways"}}},
+			wantErr:  ErrInvalidSortType,
+		},
+		{
+			name:     "model error",
+			question: "anything",
+			modelErr: errors.New("quota exceeded"),
+			wantErr:  errors.New("quota exceeded"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator(fakeModel{query: tt.query, err: tt.modelErr}, testFields...)
+
+			got, err := translator.Translate(context.Background(), tt.question)
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				if tt.modelErr == nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDefineFlow(t *testing.T) {
+	g := genkit.Init(context.Background())
+	translator := NewTranslator(fakeModel{query: Query{
+		Filters: []Filter{{Field: "amount", Operator: "gte", Value: "10"}},
+	}}, testFields...)
+
+	flow := DefineFlow(g, translator)
+
+	criteria, err := flow.Run(context.Background(), "postings of at least 10")
+	assert.NoError(t, err)
+	assert.Equal(t, dafi.Filters{{Field: "amount", Operator: dafi.GreaterOrEqual, Value: "10", ChainingKey: dafi.And}}, criteria.Filters)
+}
+
+func TestDefineFlow_InvalidField(t *testing.T) {
+	g := genkit.Init(context.Background())
+	translator := NewTranslator(fakeModel{query: Query{
+		Filters: []Filter{{Field: "secret", Operator: "eq", Value: "10"}},
+	}}, testFields...)
+
+	flow := DefineFlow(g, translator)
+
+	_, err := flow.Run(context.Background(), "postings by secret")
+	assert.ErrorIs(t, err, ErrInvalidField)
+}
+
+func TestBuildSystemPrompt(t *testing.T) {
+	prompt := buildSystemPrompt(testFields)
+
+	assert.Contains(t, prompt, "- amount: posting amount\n")
+	assert.Contains(t, prompt, "- tag: posting tag\n")
+}
+
+func TestGenkitModel_Generate(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    Query
+		wantErr bool
+	}{
+		{
+			name:   "structured output",
+			output: `{"filters":[{"field":"amount","operator":"gt","value":"500"}],"limit":10}`,
+			want: Query{
+				Filters: []Filter{{Field: "amount", Operator: "gt", Value: "500"}},
+				Limit:   10,
+			},
+		},
+		{
+			name:    "invalid output",
+			output:  `not json`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := genkit.Init(context.Background())
+			genkit.DefineModel(g, "test/fake", &ai.ModelOptions{Supports: &ai.ModelSupports{Multiturn: true, SystemRole: true, Constrained: ai.ConstrainedSupportAll}},
+				func(context.Context, *ai.ModelRequest, ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+					return &ai.ModelResponse{Message: ai.NewModelMessage(ai.NewTextPart(tt.output))}, nil
+				},
+			)
+
+			got, err := NewGenkitModel(g, "test/fake").Generate(context.Background(), "expenses over 500", testFields)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
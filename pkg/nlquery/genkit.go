@@ -0,0 +1,91 @@
+package nlquery
+
+import (
+	"context"
+	"strings"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/samber/oops"
+)
+
+// FlowName is the name the search flow is registered under in Genkit.
+const FlowName = "naturalLanguageSearch"
+
+const systemPrompt = `You translate questions about ledger data into structured filters.
+Only use the fields listed below, never invent new ones.
+Allowed operators: eq, ne, gt, gte, lt, lte, in, nin, contains, ncontains, isnull, isnnull.
+Use a comma separated value for in and nin. Chaining keys are AND or OR, sort types are ASC or DESC.
+Dates must be formatted as YYYY-MM-DD.
+
+Fields:
+`
+
+// Ensure GenkitModel implements Model.
+var _ Model = (*GenkitModel)(nil)
+
+// GenkitModel is a Model backed by a Genkit generative model.
+type GenkitModel struct {
+	g         *genkit.Genkit
+	modelName string
+}
+
+// NewGenkitModel creates a new GenkitModel using the given model name (e.g. "googleai/gemini-2.5-flash").
+// An empty model name uses the default model configured in Genkit.
+func NewGenkitModel(g *genkit.Genkit, modelName string) *GenkitModel {
+	return &GenkitModel{
+		g:         g,
+		modelName: modelName,
+	}
+}
+
+// Generate asks the model for a structured Query.
+func (m *GenkitModel) Generate(ctx context.Context, question string, fields []Field) (Query, error) {
+	opts := []ai.GenerateOption{
+		ai.WithSystem(buildSystemPrompt(fields)),
+		ai.WithPrompt(question),
+	}
+	if m.modelName != "" {
+		opts = append(opts, ai.WithModelName(m.modelName))
+	}
+
+	query, _, err := genkit.GenerateData[Query](ctx, m.g, opts...)
+	if err != nil {
+		return Query{}, oops.
+			Code("nlquery_model_failed").
+			Wrapf(err, "failed to generate query")
+	}
+
+	if query == nil {
+		return Query{}, oops.
+			Code("nlquery_empty_output").
+			Wrap(ErrEmptyOutput)
+	}
+
+	return *query, nil
+}
+
+// DefineFlow registers the search flow in Genkit so it can be traced and run from the developer UI.
+// The flow returns the criteria validated by Translator.Translate, ready for the normal query path.
+func DefineFlow(g *genkit.Genkit, translator *Translator) *core.Flow[string, dafi.Criteria, struct{}] {
+	return genkit.DefineFlow(g, FlowName, translator.Translate)
+}
+
+func buildSystemPrompt(fields []Field) string {
+	builder := strings.Builder{}
+	builder.WriteString(systemPrompt)
+
+	for _, field := range fields {
+		builder.WriteString("- ")
+		builder.WriteString(field.Name)
+		if field.Description != "" {
+			builder.WriteString(": ")
+			builder.WriteString(field.Description)
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,155 @@
+package sqlcraft
+
+import (
+	"strings"
+
+	"backend.atomicledger.com/pkg/dafi"
+)
+
+const countAll = "*"
+
+var psqlFunctionByAggregateFunction = map[dafi.AggregateFunction]string{
+	dafi.Count: "COUNT",
+	dafi.Sum:   "SUM",
+	dafi.Avg:   "AVG",
+	dafi.Min:   "MIN",
+	dafi.Max:   "MAX",
+}
+
+// Count creates a COUNT aggregation. Use "*" to count rows.
+func Count(field string) dafi.Aggregation {
+	return dafi.Aggregation{Function: dafi.Count, Field: field}
+}
+
+// CountDistinct creates a COUNT(DISTINCT field) aggregation.
+func CountDistinct(field string) dafi.Aggregation {
+	return dafi.Aggregation{Function: dafi.Count, Field: field, Distinct: true}
+}
+
+// Sum creates a SUM aggregation.
+func Sum(field string) dafi.Aggregation {
+	return dafi.Aggregation{Function: dafi.Sum, Field: field}
+}
+
+// Avg creates an AVG aggregation.
+func Avg(field string) dafi.Aggregation {
+	return dafi.Aggregation{Function: dafi.Avg, Field: field}
+}
+
+// Min creates a MIN aggregation.
+func Min(field string) dafi.Aggregation {
+	return dafi.Aggregation{Function: dafi.Min, Field: field}
+}
+
+// Max creates a MAX aggregation.
+func Max(field string) dafi.Aggregation {
+	return dafi.Aggregation{Function: dafi.Max, Field: field}
+}
+
+// BuildAggregate builds the aggregate expression without its alias, e.g. SUM(amount).
+// The field is converted to its SQL column when a mapping is provided.
+func BuildAggregate(aggregation dafi.Aggregation, sqlColumnByDomainField map[string]string) (string, error) {
+	return BuildAggregateFor(Postgres, aggregation, sqlColumnByDomainField)
+}
+
+// BuildAggregateFor is like BuildAggregate but validates identifiers with the identifier quote of dialect.
+func BuildAggregateFor(dialect Dialect, aggregation dafi.Aggregation, sqlColumnByDomainField map[string]string) (string, error) {
+	return buildAggregate(dialectRenderState(dialect), aggregation, sqlColumnByDomainField)
+}
+
+func buildAggregate(state renderState, aggregation dafi.Aggregation, sqlColumnByDomainField map[string]string) (string, error) {
+	function, ok := psqlFunctionByAggregateFunction[aggregation.Function]
+	if !ok {
+		return "", ErrInvalidOperator
+	}
+
+	column := aggregation.Field
+	switch {
+	case column == countAll:
+		if aggregation.Function != dafi.Count || aggregation.Distinct {
+			return "", ErrInvalidFieldName
+		}
+	case len(sqlColumnByDomainField) > 0:
+		sqlColumn, ok := sqlColumnByDomainField[column]
+		if !ok {
+			return "", ErrInvalidFieldName
+		}
+		column = sqlColumn
+	case column == "":
+		return "", ErrInvalidFieldName
+	default:
+		if err := state.validateIdentifiers(column); err != nil {
+			return "", err
+		}
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(function)
+	builder.WriteString("(")
+	if aggregation.Distinct {
+		builder.WriteString("DISTINCT ")
+	}
+	builder.WriteString(column)
+	builder.WriteString(")")
+
+	return builder.String(), nil
+}
+
+// buildAggregates builds the select list entries for the aggregations and returns
+// the aggregate expression by alias so HAVING filters can reference them.
+func buildAggregates(state renderState, aggregations dafi.Aggregations, sqlColumnByDomainField map[string]string) ([]string, map[string]string, error) {
+	selects := make([]string, 0, len(aggregations))
+	expressionByAlias := make(map[string]string, len(aggregations))
+
+	for _, aggregation := range aggregations {
+		expression, err := buildAggregate(state, aggregation, sqlColumnByDomainField)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if aggregation.Alias == "" {
+			selects = append(selects, expression)
+
+			continue
+		}
+
+		if !isIdentifier(aggregation.Alias) {
+			return nil, nil, ErrInvalidFieldName
+		}
+
+		selects = append(selects, expression+" AS "+aggregation.Alias)
+		expressionByAlias[aggregation.Alias] = expression
+	}
+
+	return selects, expressionByAlias, nil
+}
+
+// buildHaving builds the HAVING clause. Filter fields can reference aggregate aliases
+// or domain fields; aliases take precedence.
+func buildHaving(state renderState, initialArgCount int, expressionByAlias, sqlColumnByDomainField map[string]string, filters dafi.Filters) (Result, error) {
+	filters = append(dafi.Filters{}, filters...)
+
+	for i, filter := range filters {
+		if expression, ok := expressionByAlias[string(filter.Field)]; ok {
+			filters[i].Field = dafi.FilterField(expression)
+
+			continue
+		}
+
+		if len(sqlColumnByDomainField) > 0 {
+			sqlColumn, ok := sqlColumnByDomainField[string(filter.Field)]
+			if !ok {
+				return Result{}, ErrInvalidFieldName
+			}
+			filters[i].Field = dafi.FilterField(sqlColumn)
+
+			continue
+		}
+
+		if err := state.validateIdentifiers(string(filter.Field)); err != nil {
+			return Result{}, err
+		}
+	}
+
+	return buildConditions(state, havingClause, initialArgCount, filters...)
+}
@@ -0,0 +1,68 @@
+package sqlcraft
+
+import (
+	"testing"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAggregate(t *testing.T) {
+	tests := []struct {
+		name                   string
+		aggregation            dafi.Aggregation
+		sqlColumnByDomainField map[string]string
+		want                   string
+		wantErr                bool
+	}{
+		{
+			name:        "count all",
+			aggregation: Count("*"),
+			want:        "COUNT(*)",
+		},
+		{
+			name:                   "sum mapped field",
+			aggregation:            Sum("amount").As("total"),
+			sqlColumnByDomainField: map[string]string{"amount": "p.amount"},
+			want:                   "SUM(p.amount)",
+		},
+		{
+			name:        "count distinct",
+			aggregation: CountDistinct("account_id"),
+			want:        "COUNT(DISTINCT account_id)",
+		},
+		{
+			name:        "min and max",
+			aggregation: Max("posted_at"),
+			want:        "MAX(posted_at)",
+		},
+		{
+			name:        "error star with sum",
+			aggregation: Sum("*"),
+			wantErr:     true,
+		},
+		{
+			name:        "error unknown function",
+			aggregation: dafi.Aggregation{Function: "median", Field: "amount"},
+			wantErr:     true,
+		},
+		{
+			name:        "error empty field",
+			aggregation: Min(""),
+			wantErr:     true,
+		},
+	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := BuildAggregateFor(dialect, tt.aggregation, tt.sqlColumnByDomainField)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			})
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package sqlcraft
+
+import (
+	"strings"
+
+	"backend.atomicledger.com/pkg/dafi"
+)
+
+// ConflictAction represents the action taken by an ON CONFLICT clause.
+type ConflictAction string
+
+const (
+	// NoConflictAction means no ON CONFLICT clause is rendered.
+	NoConflictAction ConflictAction = ""
+	// DoNothingAction represents ON CONFLICT ... DO NOTHING.
+	DoNothingAction ConflictAction = "DO NOTHING"
+	// DoUpdateAction represents ON CONFLICT ... DO UPDATE SET.
+	DoUpdateAction ConflictAction = "DO UPDATE SET"
+)
+
+// Excluded references the row proposed for insertion, e.g. Excluded("amount") renders EXCLUDED.amount.
+// It can be used as a ConflictSet value and as a filter value in the DO UPDATE WHERE clause,
+// any other filter clause returns ErrInvalidExcluded.
+type Excluded string
+
+// String returns the qualified EXCLUDED reference.
+func (e Excluded) String() string {
+	return "EXCLUDED." + string(e)
+}
+
+// ConflictSet represents a single column assignment in ON CONFLICT DO UPDATE SET.
+type ConflictSet struct {
+	Column string
+	Value  any
+}
+
+// Set creates a ConflictSet assigning value to column.
+// Values of type Excluded are rendered as EXCLUDED references, everything else as a placeholder.
+func Set(column string, value any) ConflictSet {
+	return ConflictSet{
+		Column: column,
+		Value:  value,
+	}
+}
+
+// SetExcluded creates one ConflictSet per column assigning the value proposed for insertion.
+func SetExcluded(columns ...string) []ConflictSet {
+	sets := make([]ConflictSet, 0, len(columns))
+	for _, column := range columns {
+		sets = append(sets, Set(column, Excluded(column)))
+	}
+
+	return sets
+}
+
+// onConflict holds the ON CONFLICT clause of an INSERT query.
+type onConflict struct {
+	columns    []string
+	constraint string
+	action     ConflictAction
+	sets       []ConflictSet
+	filters    dafi.Filters
+}
+
+func (c onConflict) hasTarget() bool {
+	return len(c.columns) > 0 || c.constraint != ""
+}
+
+// toSQL builds the ON CONFLICT clause, numbering placeholders after initialArgCount.
+// The DO UPDATE WHERE filter fields are mapped with sqlColumnByDomainField when it is provided.
+func (c onConflict) toSQL(state renderState, initialArgCount int, sqlColumnByDomainField map[string]string) (Result, error) {
+	if c.action == NoConflictAction {
+		if c.hasTarget() {
+			return Result{}, ErrMissingConflictAction
+		}
+
+		return Result{}, nil
+	}
+
+	if c.action == DoUpdateAction && !c.hasTarget() {
+		return Result{}, ErrMissingConflictTarget
+	}
+
+	if c.action == DoUpdateAction && len(c.sets) == 0 {
+		return Result{}, ErrEmptyColumns
+	}
+
+	if err := c.validate(state); err != nil {
+		return Result{}, err
+	}
+
+	feature := OnConflictFeature
+	if c.constraint != "" {
+		feature = ConflictConstraintFeature
+	}
+
+	if err := state.requireFeature(feature); err != nil {
+		return Result{}, err
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(" ON CONFLICT")
+
+	if c.constraint != "" {
+		builder.WriteString(" ON CONSTRAINT ")
+		builder.WriteString(c.constraint)
+	} else if len(c.columns) > 0 {
+		builder.WriteString(" (")
+		builder.WriteString(strings.Join(c.columns, ", "))
+		builder.WriteString(")")
+	}
+
+	builder.WriteString(" ")
+	builder.WriteString(string(c.action))
+
+	args := []any{}
+	if c.action == DoUpdateAction {
+		builder.WriteString(" ")
+
+		for i, set := range c.sets {
+			builder.WriteString(set.Column)
+			builder.WriteString(" = ")
+
+			if excluded, ok := set.Value.(Excluded); ok {
+				builder.WriteString(excluded.String())
+			} else {
+				builder.WriteString(state.dialect.Placeholder(initialArgCount + len(args) + 1))
+				args = append(args, set.Value)
+			}
+
+			if i < len(c.sets)-1 {
+				builder.WriteString(", ")
+			}
+		}
+
+		if len(c.filters) > 0 {
+			filters, err := mapFilterFields(state, sqlColumnByDomainField, c.filters)
+			if err != nil {
+				return Result{}, err
+			}
+
+			whereResult, err := buildConditions(state, conflictWhereClause, initialArgCount+len(args), filters...)
+			if err != nil {
+				return Result{}, err
+			}
+			args = append(args, whereResult.Args...)
+
+			builder.WriteString(whereResult.SQL)
+		}
+	}
+
+	return Result{
+		SQL:  builder.String(),
+		Args: args,
+	}, nil
+}
+
+// validate checks the conflict target, assignment columns and EXCLUDED references in strict mode.
+func (c onConflict) validate(state renderState) error {
+	if err := state.validateIdentifiers(c.columns...); err != nil {
+		return err
+	}
+
+	if c.constraint != "" {
+		if err := state.validateIdentifiers(c.constraint); err != nil {
+			return err
+		}
+	}
+
+	for _, set := range c.sets {
+		if err := state.validateIdentifiers(set.Column); err != nil {
+			return err
+		}
+
+		if excluded, ok := set.Value.(Excluded); ok {
+			if err := state.validateIdentifiers(string(excluded)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, filter := range c.filters {
+		if excluded, ok := filter.Value.(Excluded); ok {
+			if err := state.validateIdentifiers(string(excluded)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
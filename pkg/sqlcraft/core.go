@@ -1,8 +1,17 @@
-// Package sqlcraft provides a fluent SQL query builder for PostgreSQL.
+// Package sqlcraft provides a fluent SQL query builder for PostgreSQL, with SQLite and MySQL dialects.
 package sqlcraft
 
 import "errors"
 
+// Query is implemented by every builder that can be rendered to SQL.
+// Queries can be nested in CTEs, FROM clauses and Subquery filter values; nested placeholders are
+// numbered after the arguments of the enclosing query.
+type Query interface {
+	ToSQL() (Result, error)
+	// render builds the query with the state of the enclosing query, numbering its placeholders after initialArgCount.
+	render(state renderState, initialArgCount int) (Result, error)
+}
+
 var (
 	// ErrEmptyValues is returned when no values are provided for the INSERT query.
 	ErrEmptyValues = errors.New("empty values in query")
@@ -10,8 +19,40 @@ var (
 	ErrEmptyColumns = errors.New("empty columns in query")
 	// ErrMissMatchValues is returned when the number of values does not match the number of columns.
 	ErrMissMatchValues = errors.New("miss match values for given columns")
+	// ErrTooManyParameters is returned when a statement needs more bind parameters than the Dialect allows.
+	ErrTooManyParameters = errors.New("too many bind parameters")
+	// ErrChunkedConflict is returned when an INSERT with an ON CONFLICT clause needs more than one statement.
+	ErrChunkedConflict = errors.New("on conflict insert cannot be split into chunks")
 	// ErrInvalidOperator is returned when an invalid operator is encountered.
 	ErrInvalidOperator = errors.New("invalid dafi operator")
 	// ErrInvalidFieldName is returned when an invalid field name is encountered.
 	ErrInvalidFieldName = errors.New("invalid field name")
+	// ErrInvalidJSONPath is returned when a JSON path segment is not a valid key or array index.
+	ErrInvalidJSONPath = errors.New("invalid json path")
+	// ErrMissingConflictTarget is returned when ON CONFLICT DO UPDATE has no conflict target.
+	ErrMissingConflictTarget = errors.New("missing conflict target")
+	// ErrMissingConflictAction is returned when a conflict target has no DO NOTHING/DO UPDATE action.
+	ErrMissingConflictAction = errors.New("missing conflict action")
+	// ErrInvalidExcluded is returned when an Excluded reference is used outside ON CONFLICT DO UPDATE.
+	ErrInvalidExcluded = errors.New("excluded references are only valid in on conflict do update")
+	// ErrEmptyQuery is returned when a CTE, subquery or compound query has nothing to render.
+	ErrEmptyQuery = errors.New("empty query")
+	// ErrMissingGroupBy is returned when HAVING is used without GROUP BY or aggregates.
+	ErrMissingGroupBy = errors.New("missing group by")
+	// ErrInvalidLock is returned when a row locking clause is incomplete or not allowed by Postgres.
+	ErrInvalidLock = errors.New("invalid row locking clause")
+	// ErrInvalidWindow is returned when a window specification or frame is not valid.
+	ErrInvalidWindow = errors.New("invalid window")
+	// ErrInvalidDistinctOn is returned when the DISTINCT ON fields do not lead the ORDER BY.
+	ErrInvalidDistinctOn = errors.New("distinct on fields must lead the order by")
+	// ErrUnsupportedByDialect is returned when a clause is not supported by the Dialect of the query.
+	ErrUnsupportedByDialect = errors.New("unsupported by dialect")
+	// ErrInvalidMapperType is returned when a Mapper is created for a type that is not a struct with db tags.
+	ErrInvalidMapperType = errors.New("invalid mapper type")
+	// ErrDuplicateColumn is returned when two struct fields are mapped to the same column.
+	ErrDuplicateColumn = errors.New("duplicate column")
+	// ErrDuplicateDomainField is returned when two struct fields are mapped to the same domain field.
+	ErrDuplicateDomainField = errors.New("duplicate domain field")
+	// ErrUnknownColumn is returned when a scanned row has a column that is not mapped.
+	ErrUnknownColumn = errors.New("unknown column")
 )
@@ -11,10 +11,13 @@ type DeleteQuery struct {
 	table            string
 	returningColumns []string
 
-	rawValues []any
+	sources []joinedTable
 
 	sqlColumnByDomainField map[string]string
 	filters                dafi.Filters
+
+	dialect Dialect
+	lenient bool
 }
 
 // DeleteFrom creates a new DeleteQuery targeting the specified table.
@@ -32,6 +35,15 @@ func (d DeleteQuery) Where(filters ...dafi.Filter) DeleteQuery {
 	return d
 }
 
+// Using adds a table to the USING clause, joined to the deleted table by condition,
+// e.g. Using("settlements s", "s.account_id = accounts.id").
+// The condition is developer defined and rendered as is; an empty condition adds no join condition.
+func (d DeleteQuery) Using(table, condition string) DeleteQuery {
+	d.sources = append(append([]joinedTable{}, d.sources...), joinedTable{table: table, condition: condition})
+
+	return d
+}
+
 // SQLColumnByDomainField sets the mapping from domain fields to SQL columns.
 func (d DeleteQuery) SQLColumnByDomainField(sqlColumnByDomainField map[string]string) DeleteQuery {
 	d.sqlColumnByDomainField = sqlColumnByDomainField
@@ -46,24 +58,74 @@ func (d DeleteQuery) Returning(columns ...string) DeleteQuery {
 	return d
 }
 
+// Dialect sets the database the query is rendered for, Postgres by default.
+// Queries nested in it are always rendered with the dialect of the outermost query.
+func (d DeleteQuery) Dialect(dialect Dialect) DeleteQuery {
+	d.dialect = dialect
+
+	return d
+}
+
+// Lenient disables strict identifier validation for this query and the queries nested in it.
+// Only use it when every identifier is developer defined.
+func (d DeleteQuery) Lenient() DeleteQuery {
+	d.lenient = true
+
+	return d
+}
+
 // ToSQL builds the SQL query and returns the Result.
 func (d DeleteQuery) ToSQL() (Result, error) {
+	return d.render(renderState{}, 0)
+}
+
+func (d DeleteQuery) render(state renderState, initialArgCount int) (Result, error) {
+	state = state.nested(d.lenient, d.dialect)
+
+	if err := state.validateTableReferences(d.table); err != nil {
+		return Result{}, err
+	}
+
+	if err := validateJoinedTables(state, d.sources); err != nil {
+		return Result{}, err
+	}
+
+	if err := state.validateReturning(d.returningColumns); err != nil {
+		return Result{}, err
+	}
+
+	if len(d.returningColumns) > 0 {
+		if err := state.requireFeature(ReturningFeature); err != nil {
+			return Result{}, err
+		}
+	}
+
+	if len(d.sources) > 0 {
+		if err := state.requireFeature(DeleteUsingFeature); err != nil {
+			return Result{}, err
+		}
+	}
+
 	builder := strings.Builder{}
 
 	builder.WriteString("DELETE FROM ")
 	builder.WriteString(d.table)
 
-	args := []any{}
-	if len(d.filters) > 0 {
-		whereResult, err := WhereSafe(len(d.rawValues), d.sqlColumnByDomainField, d.filters...)
-		if err != nil {
-			return Result{}, err
-		}
-		args = whereResult.Args
+	if len(d.sources) > 0 {
+		builder.WriteString(" USING ")
+		builder.WriteString(joinedTableNames(d.sources))
+	}
 
-		builder.WriteString(whereResult.SQL)
+	whereResult, err := buildJoinedWhere(state, initialArgCount, d.sources, d.sqlColumnByDomainField, d.filters)
+	if err != nil {
+		return Result{}, err
 	}
 
+	args := []any{}
+	args = append(args, whereResult.Args...)
+
+	builder.WriteString(whereResult.SQL)
+
 	if len(d.returningColumns) > 0 {
 		builder.WriteString(" RETURNING ")
 		builder.WriteString(strings.Join(d.returningColumns, ", "))
@@ -50,16 +50,44 @@ func TestDeleteQuery_ToSQL(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "delete using staging table",
+			query: DeleteFrom("holds h").Using("settlement_staging s", "s.hold_id = h.id").
+				Where(dafi.Filter{Field: "batch", Value: 42}, dafi.Filter{Field: "expired", Operator: dafi.Is, Value: true}).
+				SQLColumnByDomainField(map[string]string{"batch": "s.batch_id", "expired": "h.expired"}).
+				Returning("h.id"),
+			want: Result{
+				SQL:  "DELETE FROM holds h USING settlement_staging s WHERE s.hold_id = h.id AND (s.batch_id = $1 AND h.expired IS $2) RETURNING h.id",
+				Args: []any{42, true},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "delete using without filters",
+			query: DeleteFrom("holds h").Using("settlement_staging s", "s.hold_id = h.id"),
+			want: Result{
+				SQL:  "DELETE FROM holds h USING settlement_staging s WHERE s.hold_id = h.id",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error using unmapped field",
+			query:   DeleteFrom("holds h").Using("settlement_staging s", "s.hold_id = h.id").Where(dafi.Filter{Field: "amount", Value: 1}).SQLColumnByDomainField(map[string]string{"batch": "s.batch_id"}),
+			want:    Result{},
+			wantErr: true,
+		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.query.ToSQL()
-			if tt.wantErr {
-				assert.Error(t, err)
-				return
-			}
-			assert.NoError(t, err)
-			assert.Equal(t, tt.want, got)
-		})
-	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.render(renderState{dialect: dialect}, 0)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
 }
@@ -0,0 +1,221 @@
+package sqlcraft
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Feature represents a clause that is not supported by every Dialect.
+type Feature string
+
+const (
+	// ReturningFeature represents RETURNING on INSERT, UPDATE and DELETE.
+	ReturningFeature Feature = "RETURNING"
+	// OnConflictFeature represents INSERT ... ON CONFLICT (columns).
+	OnConflictFeature Feature = "ON CONFLICT"
+	// ConflictConstraintFeature represents INSERT ... ON CONFLICT ON CONSTRAINT name.
+	ConflictConstraintFeature Feature = "ON CONFLICT ON CONSTRAINT"
+	// DistinctOnFeature represents SELECT DISTINCT ON.
+	DistinctOnFeature Feature = "DISTINCT ON"
+	// RowLockFeature represents FOR UPDATE and FOR SHARE with OF, NOWAIT and SKIP LOCKED.
+	RowLockFeature Feature = "FOR UPDATE"
+	// KeyRowLockFeature represents FOR NO KEY UPDATE and FOR KEY SHARE.
+	KeyRowLockFeature Feature = "FOR NO KEY UPDATE"
+	// UpdateFromFeature represents UPDATE ... FROM.
+	UpdateFromFeature Feature = "UPDATE FROM"
+	// DeleteUsingFeature represents DELETE ... USING.
+	DeleteUsingFeature Feature = "DELETE USING"
+	// JSONFeature represents JSONB paths and the @>, ?, ?|, ?& and @@ operators.
+	JSONFeature Feature = "JSONB"
+	// IntersectExceptFeature represents the INTERSECT and EXCEPT set operations.
+	IntersectExceptFeature Feature = "INTERSECT/EXCEPT"
+)
+
+// Dialect renders the parts of a query that differ between databases.
+type Dialect interface {
+	// Name returns the name of the database.
+	Name() string
+	// Placeholder returns the bind parameter for the 1-based position, e.g. $1 or ?.
+	Placeholder(position int) string
+	// IdentifierQuote returns the character quoting identifiers, e.g. a double quote or a backtick.
+	IdentifierQuote() byte
+	// QuoteIdentifier quotes name so it is always interpreted as a single identifier.
+	QuoteIdentifier(name string) string
+	// CaseInsensitiveMatch returns a case-insensitive LIKE comparison of column with placeholder.
+	CaseInsensitiveMatch(column, placeholder string, negate bool) string
+	// Pagination returns the LIMIT and optional OFFSET clauses with a leading space.
+	Pagination(limit, offset uint64, withOffset bool) string
+	// MaxParameters returns the maximum number of bind parameters in a single statement.
+	MaxParameters() int
+	// Supports reports whether the database supports the feature.
+	Supports(feature Feature) bool
+}
+
+const (
+	// maxPostgresParameters is the limit of the 16-bit parameter count in the Postgres wire protocol.
+	maxPostgresParameters = 65535
+	// maxSQLiteParameters is the default SQLITE_MAX_VARIABLE_NUMBER since SQLite 3.32.
+	maxSQLiteParameters = 32766
+	// maxMySQLParameters is the limit of the 16-bit parameter count in MySQL prepared statements.
+	maxMySQLParameters = 65535
+)
+
+// Every builder renders for Postgres unless another dialect is set with its Dialect method.
+var (
+	// Postgres is the default dialect.
+	Postgres Dialect = postgresDialect{}
+	// SQLite renders queries for SQLite 3.35 or newer.
+	SQLite Dialect = sqliteDialect{}
+	// MySQL renders queries for MySQL 8.0 or newer.
+	MySQL Dialect = mysqlDialect{}
+)
+
+// requireFeature returns ErrUnsupportedByDialect when the dialect of the query does not support feature.
+func (r renderState) requireFeature(feature Feature) error {
+	if r.dialect.Supports(feature) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s in %s", ErrUnsupportedByDialect, feature, r.dialect.Name())
+}
+
+// quoteIdentifier quotes name with quote, doubling the quotes inside it.
+func quoteIdentifier(quote byte, name string) string {
+	q := string(quote)
+
+	return q + strings.ReplaceAll(name, q, q+q) + q
+}
+
+// limitOffset renders the standard LIMIT n [OFFSET m] clauses.
+func limitOffset(limit, offset uint64, withOffset bool) string {
+	builder := strings.Builder{}
+	builder.WriteString(" LIMIT ")
+	builder.WriteString(strconv.FormatUint(limit, 10))
+
+	if withOffset {
+		builder.WriteString(" OFFSET ")
+		builder.WriteString(strconv.FormatUint(offset, 10))
+	}
+
+	return builder.String()
+}
+
+// likeMatch renders column [NOT] <operator> placeholder.
+func likeMatch(operator, column, placeholder string, negate bool) string {
+	if negate {
+		return column + " NOT " + operator + " " + placeholder
+	}
+
+	return column + " " + operator + " " + placeholder
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) Placeholder(position int) string {
+	return "$" + strconv.Itoa(position)
+}
+
+func (postgresDialect) IdentifierQuote() byte {
+	return '"'
+}
+
+func (d postgresDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(d.IdentifierQuote(), name)
+}
+
+func (postgresDialect) CaseInsensitiveMatch(column, placeholder string, negate bool) string {
+	return likeMatch("ILIKE", column, placeholder, negate)
+}
+
+func (postgresDialect) Pagination(limit, offset uint64, withOffset bool) string {
+	return limitOffset(limit, offset, withOffset)
+}
+
+func (postgresDialect) MaxParameters() int {
+	return maxPostgresParameters
+}
+
+func (postgresDialect) Supports(Feature) bool {
+	return true
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (sqliteDialect) IdentifierQuote() byte {
+	return '"'
+}
+
+func (d sqliteDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(d.IdentifierQuote(), name)
+}
+
+// CaseInsensitiveMatch uses LIKE, which SQLite compares case-insensitively for ASCII characters.
+func (sqliteDialect) CaseInsensitiveMatch(column, placeholder string, negate bool) string {
+	return likeMatch("LIKE", column, placeholder, negate)
+}
+
+func (sqliteDialect) Pagination(limit, offset uint64, withOffset bool) string {
+	return limitOffset(limit, offset, withOffset)
+}
+
+func (sqliteDialect) MaxParameters() int {
+	return maxSQLiteParameters
+}
+
+func (sqliteDialect) Supports(feature Feature) bool {
+	switch feature {
+	case ReturningFeature, OnConflictFeature, UpdateFromFeature, IntersectExceptFeature:
+		return true
+	default:
+		return false
+	}
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (mysqlDialect) IdentifierQuote() byte {
+	return '`'
+}
+
+func (d mysqlDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(d.IdentifierQuote(), name)
+}
+
+// CaseInsensitiveMatch uses LIKE, which MySQL compares case-insensitively with the default _ci collations.
+func (mysqlDialect) CaseInsensitiveMatch(column, placeholder string, negate bool) string {
+	return likeMatch("LIKE", column, placeholder, negate)
+}
+
+func (mysqlDialect) Pagination(limit, offset uint64, withOffset bool) string {
+	return limitOffset(limit, offset, withOffset)
+}
+
+func (mysqlDialect) MaxParameters() int {
+	return maxMySQLParameters
+}
+
+// Supports only reports row locks, INTERSECT and EXCEPT need MySQL 8.0.31 and are not supported.
+func (mysqlDialect) Supports(feature Feature) bool {
+	return feature == RowLockFeature
+}
@@ -0,0 +1,429 @@
+package sqlcraft
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	dialects = []Dialect{Postgres, SQLite, MySQL}
+
+	placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+	iLikePattern       = regexp.MustCompile(`(\S+) (NOT )?ILIKE (\$\d+)`)
+
+	// featurePatterns find the clauses gated by a Feature in the Postgres SQL of a test case.
+	featurePatterns = map[Feature]func(sql string) bool{
+		ReturningFeature:          regexp.MustCompile(` RETURNING `).MatchString,
+		OnConflictFeature:         regexp.MustCompile(` ON CONFLICT [(D]`).MatchString,
+		ConflictConstraintFeature: regexp.MustCompile(` ON CONFLICT ON CONSTRAINT `).MatchString,
+		DistinctOnFeature:         regexp.MustCompile(`DISTINCT ON \(`).MatchString,
+		RowLockFeature:            regexp.MustCompile(` FOR (NO KEY UPDATE|UPDATE|KEY SHARE|SHARE)\b`).MatchString,
+		KeyRowLockFeature:         regexp.MustCompile(` FOR (NO KEY UPDATE|KEY SHARE)\b`).MatchString,
+		UpdateFromFeature:         clauseWithin("UPDATE ", " FROM "),
+		DeleteUsingFeature:        clauseWithin("DELETE FROM ", " USING "),
+		JSONFeature:               regexp.MustCompile(` (#>>?|@>|\?[|&]?|@@) `).MatchString,
+		IntersectExceptFeature:    regexp.MustCompile(` (INTERSECT|EXCEPT) `).MatchString,
+	}
+)
+
+// clauseWithin returns a matcher reporting whether a statement starting with keyword contains clause
+// outside of parentheses, e.g. the FROM of an UPDATE but not the FROM of a sub-query in its WHERE.
+func clauseWithin(keyword, clause string) func(sql string) bool {
+	return func(sql string) bool {
+		for start := strings.Index(sql, keyword); start >= 0; {
+			depth := 0
+			for i := start + len(keyword); i < len(sql) && depth >= 0; i++ {
+				switch sql[i] {
+				case '(':
+					depth++
+				case ')':
+					depth--
+				default:
+					if depth == 0 && strings.HasPrefix(sql[i:], clause) {
+						return true
+					}
+				}
+			}
+
+			next := strings.Index(sql[start+len(keyword):], keyword)
+			if next < 0 {
+				return false
+			}
+			start += len(keyword) + next
+		}
+
+		return false
+	}
+}
+
+// forEachDialect runs fn once per dialect in a sub-test named after it.
+func forEachDialect(t *testing.T, fn func(t *testing.T, dialect Dialect)) {
+	t.Helper()
+
+	for _, dialect := range dialects {
+		t.Run(dialect.Name(), func(t *testing.T) {
+			fn(t, dialect)
+		})
+	}
+}
+
+// inDialect converts a result written for Postgres to the output expected from dialect. When the SQL uses
+// a clause the dialect does not support, ErrUnsupportedByDialect is expected instead.
+func inDialect(dialect Dialect, want Result) (Result, error) {
+	for feature, uses := range featurePatterns {
+		if !dialect.Supports(feature) && uses(want.SQL) {
+			return Result{}, ErrUnsupportedByDialect
+		}
+	}
+
+	sql := iLikePattern.ReplaceAllStringFunc(want.SQL, func(match string) string {
+		parts := iLikePattern.FindStringSubmatch(match)
+
+		return dialect.CaseInsensitiveMatch(parts[1], parts[3], parts[2] != "")
+	})
+
+	want.SQL = placeholderPattern.ReplaceAllStringFunc(sql, func(match string) string {
+		position, _ := strconv.Atoi(match[1:])
+
+		return dialect.Placeholder(position)
+	})
+
+	return want, nil
+}
+
+// assertInDialect compares the result rendered for dialect with want, written for Postgres.
+func assertInDialect(t *testing.T, dialect Dialect, want Result, got Result, err error) {
+	t.Helper()
+
+	want, wantErr := inDialect(dialect, want)
+	if wantErr != nil {
+		assert.ErrorIs(t, err, wantErr)
+
+		return
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestDialect_QuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"a""b"`, QuoteIdentifier(`a"b`))
+	assert.Equal(t, `"a""b"`, SQLite.QuoteIdentifier(`a"b`))
+	assert.Equal(t, "`a``b`", MySQL.QuoteIdentifier("a`b"))
+
+	assert.True(t, isSafeIdentifier(MySQL.IdentifierQuote(), MySQL.QuoteIdentifier("a`b")))
+	assert.False(t, isSafeIdentifier(MySQL.IdentifierQuote(), QuoteIdentifier("a")))
+	assert.False(t, IsSafeIdentifier(MySQL.QuoteIdentifier("a")))
+}
+
+func TestQuery_Dialect(t *testing.T) {
+	filter := dafi.Filter{Field: "id", Value: 1}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  Result
+	}{
+		{
+			name:  "select",
+			query: Select("id").From("accounts").Where(filter).Dialect(MySQL),
+			want:  Result{SQL: "SELECT id FROM accounts WHERE id = ?", Args: []any{1}},
+		},
+		{
+			name:  "insert",
+			query: InsertInto("accounts").WithColumns("id").WithValues(1).Dialect(SQLite),
+			want:  Result{SQL: "INSERT INTO accounts (id) VALUES (?)", Args: []any{1}},
+		},
+		{
+			name:  "update",
+			query: Update("accounts").WithColumns("status").WithValues("closed").Where(filter).Dialect(MySQL),
+			want:  Result{SQL: "UPDATE accounts SET status = ? WHERE id = ?", Args: []any{"closed", 1}},
+		},
+		{
+			name:  "delete",
+			query: DeleteFrom("accounts").Where(filter).Dialect(SQLite),
+			want:  Result{SQL: "DELETE FROM accounts WHERE id = ?", Args: []any{1}},
+		},
+		{
+			name:  "default postgres",
+			query: DeleteFrom("accounts").Where(filter),
+			want:  Result{SQL: "DELETE FROM accounts WHERE id = $1", Args: []any{1}},
+		},
+		{
+			name:  "nil restores postgres",
+			query: DeleteFrom("accounts").Where(filter).Dialect(nil),
+			want:  Result{SQL: "DELETE FROM accounts WHERE id = $1", Args: []any{1}},
+		},
+		{
+			name: "nested queries use the outermost dialect",
+			query: With("open", Select("id").From("accounts").Where(filter).Dialect(Postgres)).
+				Query(Union(Select("id").From("open"), Select("id").From("archive").Where(filter).Dialect(SQLite))).
+				Dialect(MySQL),
+			want: Result{
+				SQL:  "WITH open AS (SELECT id FROM accounts WHERE id = ?) SELECT id FROM open UNION SELECT id FROM archive WHERE id = ?",
+				Args: []any{1, 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.ToSQL()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDialect_Helpers(t *testing.T) {
+	filters := dafi.Filters{
+		{Field: "amount", Operator: dafi.Greater, Value: 10},
+		{Field: "id", Operator: dafi.In, Value: []int{1, 2}},
+	}
+	sorts := dafi.Sorts{{Field: "`rank`", Type: dafi.Desc}}
+
+	got, err := WhereFor(MySQL, 0, filters...)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{SQL: " WHERE amount > ? AND id IN (?, ?)", Args: []any{10, 1, 2}}, got)
+
+	got, err = WhereSafeFor(SQLite, 0, map[string]string{"total": "amount"}, dafi.Filter{Field: "total", Value: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, Result{SQL: " WHERE amount = ?", Args: []any{5}}, got)
+
+	got, err = WhereFor(nil, 2, filters...)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{SQL: " WHERE amount > $3 AND id IN ($4, $5)", Args: []any{10, 1, 2}}, got)
+
+	_, err = WhereFor(SQLite, 0, dafi.Filter{Field: "meta", Path: []string{"tier"}, Value: "gold"})
+	assert.ErrorIs(t, err, ErrUnsupportedByDialect)
+
+	assert.Equal(t, Result{SQL: "(?, ?)", Args: []any{1, 2}}, InFor(SQLite, []int{1, 2}, 1))
+
+	orderBy, err := BuildOrderByFor(MySQL, sorts, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, " ORDER BY `rank` DESC", orderBy)
+
+	_, err = BuildOrderBy(sorts, nil)
+	assert.ErrorIs(t, err, ErrInvalidFieldName)
+
+	groupBy, err := BuildGroupByFor(MySQL, []string{"`key`"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, " GROUP BY `key`", groupBy)
+
+	aggregate, err := BuildAggregateFor(MySQL, Sum("`value`"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "SUM(`value`)", aggregate)
+
+	pagination := dafi.Pagination{PageNumber: 3, PageSize: 10}
+	assert.Equal(t, BuildPagination(pagination), BuildPaginationFor(nil, pagination))
+	assert.Equal(t, " LIMIT 10 OFFSET 20", BuildPaginationFor(SQLite, pagination))
+}
+
+func TestDialect_ToSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   Query
+		want    Result
+		wantErr error
+	}{
+		{
+			name:    "sqlite positional placeholders",
+			dialect: SQLite,
+			query: Select("id", "name").From("accounts").
+				Where(dafi.Filter{Field: "name", Operator: dafi.Contains, Value: "cash"}, dafi.Filter{Field: "id", Operator: dafi.In, Value: []int{1, 2}}).
+				Limit(10).Page(3),
+			want: Result{
+				SQL:  "SELECT id, name FROM accounts WHERE name LIKE ? AND id IN (?, ?) LIMIT 10 OFFSET 20",
+				Args: []any{"%cash%", 1, 2},
+			},
+		},
+		{
+			name:    "mysql nested queries keep argument order",
+			dialect: MySQL,
+			query: With("usd", Select("id").From("accounts").Where(dafi.Filter{Field: "currency", Value: "USD"})).
+				Query(Update("accounts").WithColumns("status").WithValues("frozen").Where(
+					dafi.Filter{Field: "id", Operator: dafi.In, Value: Subquery(Select("id").From("usd"))},
+					dafi.Filter{Field: "name", Operator: dafi.NotContains, Value: "test"},
+				)),
+			want: Result{
+				SQL:  "WITH usd AS (SELECT id FROM accounts WHERE currency = ?) UPDATE accounts SET status = ? WHERE id IN (SELECT id FROM usd) AND name NOT LIKE ?",
+				Args: []any{"USD", "frozen", "%test%"},
+			},
+		},
+		{
+			name:    "sqlite upsert",
+			dialect: SQLite,
+			query:   InsertInto("balances").WithColumns("account_id", "amount").WithValues(1, 10).OnConflict("account_id").DoUpdateSet(Set("amount", Excluded("amount"))).Returning("amount"),
+			want: Result{
+				SQL:  "INSERT INTO balances (account_id, amount) VALUES (?, ?) ON CONFLICT (account_id) DO UPDATE SET amount = EXCLUDED.amount RETURNING amount",
+				Args: []any{1, 10},
+			},
+		},
+		{
+			name:    "sqlite update from",
+			dialect: SQLite,
+			query:   Update("accounts a").WithColumns("balance").WithValues(ColumnRef("s.amount")).From("staging s", "s.account_id = a.id").Where(dafi.Filter{Field: "a.id", Value: 7}),
+			want: Result{
+				SQL:  "UPDATE accounts a SET balance = s.amount FROM staging s WHERE s.account_id = a.id AND (a.id = ?)",
+				Args: []any{7},
+			},
+		},
+		{
+			name:    "sqlite intersect",
+			dialect: SQLite,
+			query:   Intersect(Select("id").From("accounts").Where(dafi.Filter{Field: "currency", Value: "USD"}), Select("account_id").From("holds")),
+			want: Result{
+				SQL:  "SELECT id FROM accounts WHERE currency = ? INTERSECT SELECT account_id FROM holds",
+				Args: []any{"USD"},
+			},
+		},
+		{
+			name:    "mysql row lock",
+			dialect: MySQL,
+			query:   Select("id").From("jobs").Where(dafi.Filter{Field: "status", Value: "queued"}).Limit(1).ForUpdate().SkipLocked(),
+			want: Result{
+				SQL:  "SELECT id FROM jobs WHERE status = ? LIMIT 1 OFFSET 0 FOR UPDATE SKIP LOCKED",
+				Args: []any{"queued"},
+			},
+		},
+		{
+			name:    "mysql backtick identifiers",
+			dialect: MySQL,
+			query:   Select("id").From("`order`").Where(dafi.Filter{Field: "`key`", Operator: dafi.IsNull}).OrderBy(dafi.Sort{Field: "`rank`", Type: dafi.Desc}),
+			want: Result{
+				SQL:  "SELECT id FROM `order` WHERE `key` IS NULL ORDER BY `rank` DESC",
+				Args: []any{},
+			},
+		},
+		{
+			name:    "mysql double quoted identifier",
+			dialect: MySQL,
+			query:   Select("id").From(`"order"`),
+			wantErr: ErrInvalidFieldName,
+		},
+		{
+			name:    "sqlite backtick identifier",
+			dialect: SQLite,
+			query:   Select("id").From("accounts").OrderBy(dafi.Sort{Field: "`rank`"}),
+			wantErr: ErrInvalidFieldName,
+		},
+		{
+			name:    "mysql returning",
+			dialect: MySQL,
+			query:   DeleteFrom("accounts").Returning("id"),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql upsert",
+			dialect: MySQL,
+			query:   InsertInto("balances").WithColumns("account_id").WithValues(1).OnConflict("account_id").DoNothing(),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "sqlite conflict constraint",
+			dialect: SQLite,
+			query:   InsertInto("balances").WithColumns("account_id").WithValues(1).OnConflictOnConstraint("balances_pkey").DoNothing(),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "sqlite distinct on",
+			dialect: SQLite,
+			query:   Select("currency").From("rates").DistinctOn("currency"),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "sqlite row lock",
+			dialect: SQLite,
+			query:   Select("id").From("jobs").ForUpdate(),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql key row lock",
+			dialect: MySQL,
+			query:   Select("id").From("jobs").ForKeyShare(),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql update from",
+			dialect: MySQL,
+			query:   Update("accounts a").WithColumns("balance").WithValues(ColumnRef("s.amount")).From("staging s", "s.account_id = a.id"),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "sqlite delete using",
+			dialect: SQLite,
+			query:   DeleteFrom("holds h").Using("staging s", "s.hold_id = h.id"),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql intersect",
+			dialect: MySQL,
+			query:   Intersect(Select("id").From("accounts"), Select("account_id").From("holds")),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql except in cte",
+			dialect: MySQL,
+			query:   With("idle", Except(Select("id").From("accounts"), Select("account_id").From("postings"))).Query(Select("id").From("idle")),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "sqlite json path",
+			dialect: SQLite,
+			query:   Select("id").From("accounts").Where(dafi.Filter{Field: "meta", Path: []string{"tier"}, Value: "gold"}),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "sqlite json key",
+			dialect: SQLite,
+			query:   Select("id").From("accounts").Where(dafi.Filter{Field: "meta", Operator: dafi.HasKey, Value: "tier"}),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql json path",
+			dialect: MySQL,
+			query:   DeleteFrom("accounts").Where(dafi.Filter{Field: "meta", Path: []string{"limits", "daily"}, Operator: dafi.IsNull}),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql json containment",
+			dialect: MySQL,
+			query:   Select("id").From("accounts").Where(dafi.Filter{Field: "meta", Operator: dafi.JSONContains, Value: `{"tier":"gold"}`}),
+			wantErr: ErrUnsupportedByDialect,
+		},
+		{
+			name:    "mysql jsonpath match in sub-query",
+			dialect: MySQL,
+			query: Select("id").From("accounts").Where(dafi.Filter{
+				Field:    "id",
+				Operator: dafi.In,
+				Value:    Subquery(Select("account_id").From("holds").Where(dafi.Filter{Field: "meta", Operator: dafi.JSONPathMatch, Value: "$.amount > 10"})),
+			}),
+			wantErr: ErrUnsupportedByDialect,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.render(renderState{dialect: tt.dialect}, 0)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				// Postgres supports every clause, so the error comes from the dialect.
+				if errors.Is(tt.wantErr, ErrUnsupportedByDialect) {
+					_, err = tt.query.ToSQL()
+					assert.NoError(t, err)
+				}
+
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
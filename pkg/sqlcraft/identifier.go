@@ -0,0 +1,223 @@
+package sqlcraft
+
+import (
+	"fmt"
+	"strings"
+
+	"backend.atomicledger.com/pkg/dafi"
+)
+
+const maxIdentifierSegments = 3
+
+// renderState carries the options of the query being rendered to its clauses and nested queries.
+//
+// Queries are rendered in strict mode unless they are marked Lenient. In strict mode every table name,
+// join target, column, sort field and sort direction that reaches the SQL text must either be resolved
+// through SQLColumnByDomainField or be a plain or quoted identifier. Violations are reported as
+// ErrInvalidFieldName. Select column expressions and join conditions are developer defined and are not validated.
+type renderState struct {
+	// dialect renders the query, nested queries always use the dialect of the outermost query.
+	dialect Dialect
+	// lenient disables strict identifier validation, nested queries inherit it.
+	lenient bool
+}
+
+// dialectRenderState returns the state of the exported clause helpers: dialect, Postgres when nil, in strict mode.
+func dialectRenderState(dialect Dialect) renderState {
+	return renderState{}.nested(false, dialect)
+}
+
+// nested returns the state of a query with the given options rendered within r. The query is lenient
+// when either query is, and uses its own dialect only when it is the outermost query, Postgres by default.
+func (r renderState) nested(lenient bool, dialect Dialect) renderState {
+	r.lenient = r.lenient || lenient
+
+	if r.dialect == nil {
+		r.dialect = dialect
+	}
+
+	if r.dialect == nil {
+		r.dialect = Postgres
+	}
+
+	return r
+}
+
+// QuoteIdentifier quotes name for Postgres so it is always interpreted as a single identifier.
+// Use the QuoteIdentifier method of the Dialect for other databases.
+func QuoteIdentifier(name string) string {
+	return Postgres.QuoteIdentifier(name)
+}
+
+// IsSafeIdentifier reports whether name is a plain (letters, digits and underscores) or
+// double quoted identifier, optionally qualified with up to two dots, e.g. schema.table.column.
+func IsSafeIdentifier(name string) bool {
+	return isSafeIdentifier(Postgres.IdentifierQuote(), name)
+}
+
+// isSafeIdentifier is like IsSafeIdentifier with quote as the identifier quote of the dialect.
+func isSafeIdentifier(quote byte, name string) bool {
+	segments := 0
+
+	for i := 0; ; {
+		if i >= len(name) {
+			return false
+		}
+
+		end, ok := identifierSegmentEnd(quote, name, i)
+		if !ok {
+			return false
+		}
+
+		segments++
+		if segments > maxIdentifierSegments {
+			return false
+		}
+
+		if end == len(name) {
+			return true
+		}
+
+		if name[end] != '.' {
+			return false
+		}
+
+		i = end + 1
+	}
+}
+
+// identifierSegmentEnd returns the index right after the identifier segment starting at start.
+func identifierSegmentEnd(quote byte, name string, start int) (int, bool) {
+	if name[start] == quote {
+		for i := start + 1; i < len(name); i++ {
+			switch name[i] {
+			case 0:
+				return 0, false
+			case quote:
+				if i+1 < len(name) && name[i+1] == quote {
+					i++
+
+					continue
+				}
+
+				return i + 1, i > start+1
+			}
+		}
+
+		return 0, false
+	}
+
+	if isDigit(name[start]) {
+		return 0, false
+	}
+
+	end := start
+	for end < len(name) && (isLetter(name[end]) || isDigit(name[end]) || name[end] == '_') {
+		end++
+	}
+
+	return end, end > start
+}
+
+// isIdentifier reports whether name is a plain SQL identifier (letters, digits and underscores).
+func isIdentifier(name string) bool {
+	if name == "" || isDigit(name[0]) {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		if !isLetter(name[i]) && !isDigit(name[i]) && name[i] != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isSafeTableReference reports whether table is an identifier optionally followed by an alias,
+// e.g. "accounts", "public.accounts a" or "accounts AS a".
+func isSafeTableReference(quote byte, table string) bool {
+	fields := strings.Fields(table)
+
+	switch len(fields) {
+	case 1:
+		return isSafeIdentifier(quote, fields[0])
+	case 2:
+		return isSafeIdentifier(quote, fields[0]) && isIdentifier(fields[1])
+	case 3:
+		return isSafeIdentifier(quote, fields[0]) && strings.EqualFold(fields[1], "AS") && isIdentifier(fields[2])
+	default:
+		return false
+	}
+}
+
+func invalidIdentifier(name string) error {
+	return fmt.Errorf("%w: %q", ErrInvalidFieldName, name)
+}
+
+// validateIdentifiers checks identifiers in strict mode.
+func (r renderState) validateIdentifiers(names ...string) error {
+	if r.lenient {
+		return nil
+	}
+
+	for _, name := range names {
+		if !isSafeIdentifier(r.dialect.IdentifierQuote(), name) {
+			return invalidIdentifier(name)
+		}
+	}
+
+	return nil
+}
+
+// validateReturning checks RETURNING columns in strict mode, allowing "*".
+func (r renderState) validateReturning(columns []string) error {
+	for _, column := range columns {
+		if column == "*" {
+			continue
+		}
+
+		if err := r.validateIdentifiers(column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTableReferences checks table names and join targets in strict mode.
+func (r renderState) validateTableReferences(tables ...string) error {
+	if r.lenient {
+		return nil
+	}
+
+	for _, table := range tables {
+		if !isSafeTableReference(r.dialect.IdentifierQuote(), table) {
+			return invalidIdentifier(table)
+		}
+	}
+
+	return nil
+}
+
+// validateSortType checks the sort direction in strict mode.
+func (r renderState) validateSortType(sortType dafi.SortType) error {
+	if r.lenient {
+		return nil
+	}
+
+	switch dafi.SortType(strings.ToUpper(string(sortType))) {
+	case dafi.Asc, dafi.Desc, dafi.None:
+		return nil
+	default:
+		return invalidIdentifier(string(sortType))
+	}
+}
@@ -0,0 +1,219 @@
+package sqlcraft
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSafeIdentifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		want       bool
+	}{
+		{name: "plain", identifier: "created_at", want: true},
+		{name: "qualified", identifier: "public.accounts.id", want: true},
+		{name: "quoted", identifier: `"Order"`, want: true},
+		{name: "quoted with escaped quote", identifier: `"a""b"`, want: true},
+		{name: "qualified quoted", identifier: `a."Select"`, want: true},
+		{name: "empty", identifier: "", want: false},
+		{name: "leading digit", identifier: "1id", want: false},
+		{name: "too many segments", identifier: "a.b.c.d", want: false},
+		{name: "trailing dot", identifier: "a.", want: false},
+		{name: "space", identifier: "id desc", want: false},
+		{name: "comment", identifier: "id--", want: false},
+		{name: "statement", identifier: "id; DROP TABLE accounts", want: false},
+		{name: "unterminated quote", identifier: `"id`, want: false},
+		{name: "empty quote", identifier: `""`, want: false},
+		{name: "function call", identifier: "lower(name)", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsSafeIdentifier(tt.identifier))
+		})
+	}
+}
+
+func TestIsSafeTableReference(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		want  bool
+	}{
+		{name: "table", table: "accounts", want: true},
+		{name: "alias", table: "public.accounts a", want: true},
+		{name: "as alias", table: "accounts AS a", want: true},
+		{name: "invalid alias", table: "accounts a;", want: false},
+		{name: "extra tokens", table: "accounts a b", want: false},
+		{name: "subquery", table: "(SELECT 1) a", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isSafeTableReference(Postgres.IdentifierQuote(), tt.table))
+		})
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"Order"`, QuoteIdentifier("Order"))
+	assert.Equal(t, `"a""b"`, QuoteIdentifier(`a"b`))
+	assert.True(t, IsSafeIdentifier(QuoteIdentifier(`x"; DROP TABLE accounts; --`)))
+}
+
+func TestLenient(t *testing.T) {
+	query := Select("id").From("accounts").OrderBy(dafi.Sort{Field: "lower(name)"})
+
+	_, err := query.ToSQL()
+	assert.ErrorIs(t, err, ErrInvalidFieldName)
+
+	got, err := query.Lenient().ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM accounts ORDER BY lower(name)", got.SQL)
+
+	// Leniency is scoped to the query and the queries nested in it.
+	_, err = query.ToSQL()
+	assert.ErrorIs(t, err, ErrInvalidFieldName)
+
+	got, err = With("ordered", query).Lenient().Query(Select("id").From("ordered")).ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH ordered AS (SELECT id FROM accounts ORDER BY lower(name)) SELECT id FROM ordered", got.SQL)
+}
+
+func FuzzSelectQuery_Identifiers(f *testing.F) {
+	seeds := []string{
+		"id",
+		"public.accounts",
+		`"Order"`,
+		"id; DROP TABLE accounts",
+		"id --",
+		"1=1 OR id",
+		"(SELECT 1)",
+		`"a"" OR 1=1 --"`,
+		"ASC, (SELECT pg_sleep(1))",
+		"OR 1=1 OR",
+		"; DELETE FROM t; --",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	const value = "fuzz-value-sentinel"
+
+	f.Fuzz(func(t *testing.T, input string) {
+		queries := map[string]SelectQuery{
+			"sort field": Select("id").From("accounts").OrderBy(dafi.Sort{Field: dafi.SortBy(input)}),
+			"sort type":  Select("id").From("accounts").OrderBy(dafi.Sort{Field: "id", Type: dafi.SortType(input)}),
+			"filter":     Select("id").From("accounts").Where(dafi.Filter{Field: dafi.FilterField(input), Value: value}),
+			"group":      Select("id").From("accounts").GroupBy(input),
+			"table":      Select("id").From(input),
+			"join":       Select("id").From("accounts a").InnerJoin(input, "a.id = b.account_id"),
+			"mapped":     Select("id").From("accounts").SQLColumnByDomainField(map[string]string{"name": "name"}).OrderBy(dafi.Sort{Field: dafi.SortBy(input)}),
+			"chaining key": Select("id").From("accounts").Where(
+				dafi.Filter{Field: "id", Value: value, ChainingKey: dafi.FilterChainingKey(input)},
+				dafi.Filter{Field: "name", Value: value},
+			),
+		}
+
+		for name, query := range queries {
+			got, err := query.ToSQL()
+			if err != nil {
+				continue
+			}
+
+			assert.NotContains(t, got.SQL, value, name)
+
+			switch name {
+			case "sort field", "filter", "group":
+				assert.True(t, IsSafeIdentifier(input), "%s: %q", name, input)
+			case "sort type":
+				assert.Contains(t, []string{"", "ASC", "DESC"}, strings.ToUpper(input), name)
+			case "table", "join":
+				assert.True(t, isSafeTableReference(Postgres.IdentifierQuote(), input), "%s: %q", name, input)
+			case "mapped":
+				assert.Equal(t, "name", input, name)
+			case "chaining key":
+				assert.Contains(t, []string{"", "AND", "OR"}, strings.ToUpper(input), name)
+			}
+		}
+
+		// URL values reach the query through the parser: fuzz the key, the operator and both chaining keys.
+		parsed := []url.Values{
+			{input: []string{"eq:" + value}},
+			{"amount": []string{input + ":" + value}},
+			{"amount": []string{"eq:" + value + ":" + input}, "name": []string{"eq:" + value}},
+			{"amount": []string{"eq:" + value}, "name": []string{"or:eq:" + value + ":" + input}},
+			{"name": []string{"sort:" + input}},
+		}
+		for _, values := range parsed {
+			criteria, err := dafi.NewQueryParser().Parse(values)
+			if err != nil {
+				continue
+			}
+
+			got, err := Select("id").From("accounts").Where(criteria.Filters...).OrderBy(criteria.Sorts...).ToSQL()
+			if err != nil {
+				continue
+			}
+
+			identifiers := []string{"id", "accounts"}
+			for _, filter := range criteria.Filters {
+				identifiers = append(identifiers, string(filter.Field))
+			}
+			for _, sort := range criteria.Sorts {
+				identifiers = append(identifiers, string(sort.Field))
+			}
+
+			assert.NotContains(t, got.SQL, value, values)
+			assert.True(t, isTokenized(got.SQL, identifiers), "%v: %q", values, got.SQL)
+		}
+	})
+}
+
+// sqlTokens are the keywords and operators the parser can put into a query next to identifiers and placeholders.
+var sqlTokens = []string{
+	"SELECT", "FROM", "WHERE", "AND", "OR", "ORDER", "BY", "ASC", "DESC", "IS", "NOT", "NULL", "IN", "ILIKE",
+	"EXISTS", "=", "<>", ">=", "<=", ">", "<", "@>", "?|", "?&", "?", "@@", "(", ")", ",",
+}
+
+// isTokenized reports whether sql consists only of sqlTokens, placeholders and the given identifiers.
+func isTokenized(sql string, identifiers []string) bool {
+	candidates := append(append([]string{}, identifiers...), sqlTokens...)
+	slices.SortFunc(candidates, func(a, b string) int { return len(b) - len(a) })
+
+	for rest := strings.TrimSpace(sql); rest != ""; rest = strings.TrimLeft(rest, " ") {
+		if placeholder := regexp.MustCompile(`^\$[0-9]+`).FindString(rest); placeholder != "" {
+			rest = rest[len(placeholder):]
+
+			continue
+		}
+
+		matched := false
+		for _, candidate := range candidates {
+			after, ok := strings.CutPrefix(rest, candidate)
+			if !ok || (isIdentifierByte(candidate[len(candidate)-1]) && after != "" && isIdentifierByte(after[0])) {
+				continue
+			}
+
+			rest = after
+			matched = true
+
+			break
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isIdentifierByte(c byte) bool {
+	return isLetter(c) || isDigit(c) || c == '_' || c == '"'
+}
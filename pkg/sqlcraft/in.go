@@ -3,12 +3,20 @@ package sqlcraft
 import (
 	"bytes"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
-// In builds an IN clause.
+// In builds an IN clause with Postgres placeholders starting at initialArgCount.
 func In(value any, initialArgCount int) Result {
+	return InFor(Postgres, value, initialArgCount)
+}
+
+// InFor is like In but uses the placeholders of dialect, Postgres when nil.
+func InFor(dialect Dialect, value any, initialArgCount int) Result {
+	return in(dialectRenderState(dialect).dialect, value, initialArgCount)
+}
+
+func in(dialect Dialect, value any, initialArgCount int) Result {
 	if value == nil {
 		return Result{}
 	}
@@ -25,8 +33,7 @@ func In(value any, initialArgCount int) Result {
 
 		args := make([]any, 0, valSlice.Len())
 		for i := range valSlice.Len() {
-			builder.WriteString("$")
-			builder.WriteString(strconv.Itoa(initialArgCount + i))
+			builder.WriteString(dialect.Placeholder(initialArgCount + i))
 			builder.WriteString(", ")
 
 			args = append(args, valSlice.Index(i).Interface())
@@ -52,8 +59,7 @@ func In(value any, initialArgCount int) Result {
 	stringValues := strings.Split(str, ",")
 	args := make([]any, 0, len(stringValues))
 	for i, v := range stringValues {
-		builder.WriteString("$")
-		builder.WriteString(strconv.Itoa(initialArgCount + i))
+		builder.WriteString(dialect.Placeholder(initialArgCount + i))
 		builder.WriteString(", ")
 
 		args = append(args, v)
@@ -1,8 +1,10 @@
 package sqlcraft
 
 import (
-	"strconv"
+	"fmt"
 	"strings"
+
+	"backend.atomicledger.com/pkg/dafi"
 )
 
 // InsertQuery represents an INSERT query.
@@ -11,6 +13,12 @@ type InsertQuery struct {
 	columns          []string
 	returningColumns []string
 	values           []any
+
+	onConflict             onConflict
+	sqlColumnByDomainField map[string]string
+
+	dialect Dialect
+	lenient bool
 }
 
 // InsertInto creates a new InsertQuery targeting the specified table.
@@ -35,6 +43,55 @@ func (i InsertQuery) WithValues(values ...any) InsertQuery {
 	return i
 }
 
+// OnConflict sets the conflict target columns for an upsert.
+func (i InsertQuery) OnConflict(columns ...string) InsertQuery {
+	i.onConflict.columns = columns
+	i.onConflict.constraint = ""
+
+	return i
+}
+
+// OnConflictOnConstraint sets the named constraint as the conflict target for an upsert.
+func (i InsertQuery) OnConflictOnConstraint(name string) InsertQuery {
+	i.onConflict.constraint = name
+	i.onConflict.columns = nil
+
+	return i
+}
+
+// DoNothing skips rows that conflict. It can be used without a conflict target.
+func (i InsertQuery) DoNothing() InsertQuery {
+	i.onConflict.action = DoNothingAction
+	i.onConflict.sets = nil
+	i.onConflict.filters = nil
+
+	return i
+}
+
+// DoUpdateSet updates the conflicting row with the given assignments. It requires a conflict target.
+func (i InsertQuery) DoUpdateSet(sets ...ConflictSet) InsertQuery {
+	i.onConflict.action = DoUpdateAction
+	i.onConflict.sets = sets
+
+	return i
+}
+
+// DoUpdateWhere only updates conflicting rows matching the filters.
+// Filter fields are mapped with SQLColumnByDomainField when a mapping is set, otherwise they must be
+// column names, optionally table qualified. Use Excluded values to compare with the proposed row.
+func (i InsertQuery) DoUpdateWhere(filters ...dafi.Filter) InsertQuery {
+	i.onConflict.filters = filters
+
+	return i
+}
+
+// SQLColumnByDomainField sets the mapping from domain fields to SQL columns used by DoUpdateWhere.
+func (i InsertQuery) SQLColumnByDomainField(sqlColumnByDomainField map[string]string) InsertQuery {
+	i.sqlColumnByDomainField = sqlColumnByDomainField
+
+	return i
+}
+
 // Returning adds a RETURNING clause to the query.
 func (i InsertQuery) Returning(columns ...string) InsertQuery {
 	i.returningColumns = columns
@@ -42,8 +99,35 @@ func (i InsertQuery) Returning(columns ...string) InsertQuery {
 	return i
 }
 
+// Dialect sets the database the query is rendered for, Postgres by default.
+// Queries nested in it are always rendered with the dialect of the outermost query.
+func (i InsertQuery) Dialect(dialect Dialect) InsertQuery {
+	i.dialect = dialect
+
+	return i
+}
+
+// Lenient disables strict identifier validation for this query and the queries nested in it.
+// Only use it when every identifier is developer defined.
+func (i InsertQuery) Lenient() InsertQuery {
+	i.lenient = true
+
+	return i
+}
+
 // ToSQL builds the SQL query and returns the Result.
+// It returns ErrTooManyParameters when the values exceed the bind parameter limit of the Dialect, use ToSQLChunks for large batches.
 func (i InsertQuery) ToSQL() (Result, error) {
+	return i.render(renderState{}, 0)
+}
+
+func (i InsertQuery) render(state renderState, initialArgCount int) (Result, error) {
+	state = state.nested(i.lenient, i.dialect)
+
+	if len(i.columns) == 0 {
+		return Result{}, ErrEmptyColumns
+	}
+
 	if len(i.values) == 0 {
 		return Result{}, ErrEmptyValues
 	}
@@ -52,6 +136,26 @@ func (i InsertQuery) ToSQL() (Result, error) {
 		return Result{}, ErrMissMatchValues
 	}
 
+	if err := state.validateTableReferences(i.table); err != nil {
+		return Result{}, err
+	}
+
+	if err := state.validateIdentifiers(i.columns...); err != nil {
+		return Result{}, err
+	}
+
+	if err := state.validateReturning(i.returningColumns); err != nil {
+		return Result{}, err
+	}
+
+	if len(i.returningColumns) > 0 {
+		if err := state.requireFeature(ReturningFeature); err != nil {
+			return Result{}, err
+		}
+	}
+
+	dialect := state.dialect
+
 	builder := strings.Builder{}
 
 	builder.WriteString("INSERT INTO ")
@@ -74,8 +178,7 @@ func (i InsertQuery) ToSQL() (Result, error) {
 			builder.WriteString("(")
 		}
 
-		builder.WriteString("$")
-		builder.WriteString(strconv.Itoa(index + 1))
+		builder.WriteString(dialect.Placeholder(initialArgCount + index + 1))
 
 		if valueRowCount == len(i.columns) {
 			builder.WriteString(")")
@@ -85,6 +188,21 @@ func (i InsertQuery) ToSQL() (Result, error) {
 		}
 	}
 
+	conflictResult, err := i.onConflict.toSQL(state, initialArgCount+len(i.values), i.sqlColumnByDomainField)
+	if err != nil {
+		return Result{}, err
+	}
+	builder.WriteString(conflictResult.SQL)
+
+	if len(i.values)+len(conflictResult.Args) > dialect.MaxParameters() {
+		return Result{}, fmt.Errorf("%w: %d exceeds %d", ErrTooManyParameters, len(i.values)+len(conflictResult.Args), dialect.MaxParameters())
+	}
+
+	args := i.values
+	if len(conflictResult.Args) > 0 {
+		args = append(append([]any{}, i.values...), conflictResult.Args...)
+	}
+
 	if len(i.returningColumns) > 0 {
 		builder.WriteString(" RETURNING ")
 		builder.WriteString(strings.Join(i.returningColumns, ", "))
@@ -92,6 +210,66 @@ func (i InsertQuery) ToSQL() (Result, error) {
 
 	return Result{
 		SQL:  builder.String(),
-		Args: i.values,
+		Args: args,
 	}, nil
 }
+
+// ToSQLChunks builds the query split into statements that stay within the bind parameter limit of the Dialect.
+// Every chunk repeats the RETURNING clause, run them in a single transaction to insert all rows atomically.
+//
+// Queries with an ON CONFLICT clause return ErrChunkedConflict when they need more than one statement: a later
+// chunk would update or skip rows inserted by an earlier one instead of failing like a single statement does.
+func (i InsertQuery) ToSQLChunks() ([]Result, error) {
+	return i.toSQLChunks(i.renderState().dialect.MaxParameters())
+}
+
+// renderState returns the state of the query rendered on its own.
+func (i InsertQuery) renderState() renderState {
+	return renderState{}.nested(i.lenient, i.dialect)
+}
+
+func (i InsertQuery) toSQLChunks(maxParameters int) ([]Result, error) {
+	if len(i.columns) == 0 {
+		return nil, ErrEmptyColumns
+	}
+
+	if len(i.values) == 0 {
+		return nil, ErrEmptyValues
+	}
+
+	if len(i.values)%len(i.columns) != 0 {
+		return nil, ErrMissMatchValues
+	}
+
+	// The conflict clause binds the same number of arguments in every chunk.
+	conflictResult, err := i.onConflict.toSQL(i.renderState(), 0, i.sqlColumnByDomainField)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsPerChunk := (maxParameters - len(conflictResult.Args)) / len(i.columns)
+	if rowsPerChunk < 1 {
+		return nil, fmt.Errorf("%w: a single row needs %d", ErrTooManyParameters, len(i.columns)+len(conflictResult.Args))
+	}
+
+	valuesPerChunk := rowsPerChunk * len(i.columns)
+	if i.onConflict.action != NoConflictAction && len(i.values) > valuesPerChunk {
+		return nil, fmt.Errorf("%w: %d rows exceed %d", ErrChunkedConflict, len(i.values)/len(i.columns), rowsPerChunk)
+	}
+
+	results := make([]Result, 0, (len(i.values)+valuesPerChunk-1)/valuesPerChunk)
+
+	for start := 0; start < len(i.values); start += valuesPerChunk {
+		chunk := i
+		chunk.values = i.values[start:min(start+valuesPerChunk, len(i.values))]
+
+		result, err := chunk.ToSQL()
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -3,6 +3,7 @@ package sqlcraft
 import (
 	"testing"
 
+	"backend.atomicledger.com/pkg/dafi"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -57,6 +58,130 @@ func TestInsert_ToSql(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "upsert do nothing without conflict target",
+			query: InsertInto("journal_entries").
+				WithColumns("idempotency_key", "amount").
+				WithValues("key-1", 100).
+				DoNothing(),
+			want: Result{
+				SQL:  "INSERT INTO journal_entries (idempotency_key, amount) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				Args: []any{"key-1", 100},
+			},
+			wantErr: false,
+		},
+		{
+			name: "upsert do nothing on constraint",
+			query: InsertInto("journal_entries").
+				WithColumns("idempotency_key", "amount").
+				WithValues("key-1", 100).
+				OnConflictOnConstraint("journal_entries_idempotency_key_key").
+				DoNothing().
+				Returning("id"),
+			want: Result{
+				SQL:  "INSERT INTO journal_entries (idempotency_key, amount) VALUES ($1, $2) ON CONFLICT ON CONSTRAINT journal_entries_idempotency_key_key DO NOTHING RETURNING id",
+				Args: []any{"key-1", 100},
+			},
+			wantErr: false,
+		},
+		{
+			name: "upsert do update with excluded references and values across multiple rows",
+			query: InsertInto("balances").
+				WithColumns("account_id", "currency", "amount").
+				WithValues(1, "USD", 100).
+				WithValues(2, "USD", 200).
+				OnConflict("account_id", "currency").
+				DoUpdateSet(Set("amount", Excluded("amount")), Set("updated_by", "projector")).
+				Returning("id"),
+			want: Result{
+				SQL:  "INSERT INTO balances (account_id, currency, amount) VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT (account_id, currency) DO UPDATE SET amount = EXCLUDED.amount, updated_by = $7 RETURNING id",
+				Args: []any{1, "USD", 100, 2, "USD", 200, "projector"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "upsert do update with conditional where",
+			query: InsertInto("balances").
+				WithColumns("account_id", "amount", "version").
+				WithValues(1, 100, 3).
+				OnConflict("account_id").
+				DoUpdateSet(SetExcluded("amount", "version")...).
+				DoUpdateWhere(
+					dafi.Filter{Field: "balances.version", Operator: dafi.Less, Value: Excluded("version")},
+					dafi.Filter{Field: "balances.locked", Operator: dafi.Equal, Value: false},
+				),
+			want: Result{
+				SQL:  "INSERT INTO balances (account_id, amount, version) VALUES ($1, $2, $3) ON CONFLICT (account_id) DO UPDATE SET amount = EXCLUDED.amount, version = EXCLUDED.version WHERE balances.version < EXCLUDED.version AND balances.locked = $4",
+				Args: []any{1, 100, 3, false},
+			},
+			wantErr: false,
+		},
+		{
+			name: "upsert do update where with mapped fields",
+			query: InsertInto("balances").
+				WithColumns("account_id", "amount", "version").
+				WithValues(1, 100, 3).
+				SQLColumnByDomainField(map[string]string{"version": "balances.version"}).
+				OnConflict("account_id").
+				DoUpdateSet(SetExcluded("amount")...).
+				DoUpdateWhere(dafi.Filter{Field: "version", Operator: dafi.LessOrEqual, Value: Excluded("version")}),
+			want: Result{
+				SQL:  "INSERT INTO balances (account_id, amount, version) VALUES ($1, $2, $3) ON CONFLICT (account_id) DO UPDATE SET amount = EXCLUDED.amount WHERE balances.version <= EXCLUDED.version",
+				Args: []any{1, 100, 3},
+			},
+			wantErr: false,
+		},
+		{
+			name: "error do update where with unmapped field",
+			query: InsertInto("balances").
+				WithColumns("account_id", "amount").
+				WithValues(1, 100).
+				SQLColumnByDomainField(map[string]string{"version": "balances.version"}).
+				OnConflict("account_id").
+				DoUpdateSet(SetExcluded("amount")...).
+				DoUpdateWhere(dafi.Filter{Field: "locked", Operator: dafi.Equal, Value: false}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error do update where with unsafe excluded reference",
+			query: InsertInto("balances").
+				WithColumns("account_id", "amount").
+				WithValues(1, 100).
+				OnConflict("account_id").
+				DoUpdateSet(SetExcluded("amount")...).
+				DoUpdateWhere(dafi.Filter{Field: "balances.version", Operator: dafi.Less, Value: Excluded("version; DROP TABLE balances")}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error do update without conflict target",
+			query: InsertInto("balances").
+				WithColumns("account_id", "amount").
+				WithValues(1, 100).
+				DoUpdateSet(SetExcluded("amount")...),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error conflict target without action",
+			query: InsertInto("balances").
+				WithColumns("account_id", "amount").
+				WithValues(1, 100).
+				OnConflict("account_id"),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error do update without assignments",
+			query: InsertInto("balances").
+				WithColumns("account_id", "amount").
+				WithValues(1, 100).
+				OnConflict("account_id").
+				DoUpdateSet(),
+			want:    Result{},
+			wantErr: true,
+		},
 		{
 			name:    "error empty values",
 			query:   InsertInto("users").WithColumns("first_name", "last_name", "email", "password").Returning("id", "created_at"),
@@ -70,15 +195,126 @@ func TestInsert_ToSql(t *testing.T) {
 			wantErr: true,
 		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.query.ToSQL()
-			if tt.wantErr {
-				assert.Error(t, err)
-				return
-			}
-			assert.NoError(t, err)
-			assert.Equal(t, tt.want, got)
-		})
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.render(renderState{dialect: dialect}, 0)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
+}
+
+func TestInsert_ToSQLChunks(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         InsertQuery
+		maxParameters int
+		want          []Result
+		wantErr       error
+	}{
+		{
+			name:          "single chunk",
+			query:         InsertInto("postings").WithColumns("account_id", "amount").WithValues(1, 100, 2, -100),
+			maxParameters: 10,
+			want: []Result{
+				{SQL: "INSERT INTO postings (account_id, amount) VALUES ($1, $2), ($3, $4)", Args: []any{1, 100, 2, -100}},
+			},
+		},
+		{
+			name:          "split in full and partial chunks",
+			query:         InsertInto("postings").WithColumns("account_id", "amount").WithValues(1, 100, 2, -100, 3, 50, 4, -50, 5, 10).Returning("id"),
+			maxParameters: 5,
+			want: []Result{
+				{SQL: "INSERT INTO postings (account_id, amount) VALUES ($1, $2), ($3, $4) RETURNING id", Args: []any{1, 100, 2, -100}},
+				{SQL: "INSERT INTO postings (account_id, amount) VALUES ($1, $2), ($3, $4) RETURNING id", Args: []any{3, 50, 4, -50}},
+				{SQL: "INSERT INTO postings (account_id, amount) VALUES ($1, $2) RETURNING id", Args: []any{5, 10}},
+			},
+		},
+		{
+			name: "conflict arguments count towards the limit",
+			query: InsertInto("balances").WithColumns("account_id", "amount").WithValues(1, 100, 2, 200).
+				OnConflict("account_id").DoUpdateSet(Set("updated_by", "settlement")),
+			maxParameters: 5,
+			want: []Result{
+				{SQL: "INSERT INTO balances (account_id, amount) VALUES ($1, $2), ($3, $4) ON CONFLICT (account_id) DO UPDATE SET updated_by = $5", Args: []any{1, 100, 2, 200, "settlement"}},
+			},
+		},
+		{
+			name: "error do update split in chunks",
+			query: InsertInto("balances").WithColumns("account_id", "amount").WithValues(1, 100, 2, 200, 3, 300).
+				OnConflict("account_id").DoUpdateSet(Set("updated_by", "settlement")),
+			maxParameters: 5,
+			wantErr:       ErrChunkedConflict,
+		},
+		{
+			name:          "error do nothing split in chunks",
+			query:         InsertInto("balances").WithColumns("account_id", "amount").WithValues(1, 100, 2, 200).OnConflict("account_id").DoNothing(),
+			maxParameters: 3,
+			wantErr:       ErrChunkedConflict,
+		},
+		{
+			name:          "error row exceeds the limit",
+			query:         InsertInto("postings").WithColumns("account_id", "amount", "currency").WithValues(1, 100, "USD"),
+			maxParameters: 2,
+			wantErr:       ErrTooManyParameters,
+		},
+		{
+			name:          "error missing columns",
+			query:         InsertInto("postings").WithValues(1, 100),
+			maxParameters: 10,
+			wantErr:       ErrEmptyColumns,
+		},
+		{
+			name:          "error missmatch values",
+			query:         InsertInto("postings").WithColumns("account_id", "amount").WithValues(1, 100, 2),
+			maxParameters: 10,
+			wantErr:       ErrMissMatchValues,
+		},
 	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.Dialect(dialect).toSQLChunks(tt.maxParameters)
+				if tt.wantErr != nil {
+					// The conflict clause is rendered before the rows are split.
+					if tt.query.onConflict.action != NoConflictAction && !dialect.Supports(OnConflictFeature) {
+						assert.ErrorIs(t, err, ErrUnsupportedByDialect)
+						return
+					}
+					assert.ErrorIs(t, err, tt.wantErr)
+					return
+				}
+
+				want := make([]Result, 0, len(tt.want))
+				for _, chunk := range tt.want {
+					chunk, wantErr := inDialect(dialect, chunk)
+					if wantErr != nil {
+						assert.ErrorIs(t, err, wantErr)
+						return
+					}
+					want = append(want, chunk)
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, want, got)
+			})
+		}
+	})
+}
+
+func TestInsert_ToSQLParameterLimit(t *testing.T) {
+	values := make([]any, Postgres.MaxParameters()+1)
+
+	_, err := InsertInto("postings").WithColumns("amount").WithValues(values...).ToSQL()
+	assert.ErrorIs(t, err, ErrTooManyParameters)
+
+	chunks, err := InsertInto("postings").WithColumns("amount").WithValues(values...).ToSQLChunks()
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 2)
+	assert.Len(t, chunks[0].Args, Postgres.MaxParameters())
+	assert.Len(t, chunks[1].Args, 1)
 }
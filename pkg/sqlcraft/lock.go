@@ -0,0 +1,119 @@
+package sqlcraft
+
+import (
+	"strings"
+)
+
+// LockStrength represents the strength of a row locking clause.
+type LockStrength string
+
+const (
+	// NoLock means no locking clause is rendered.
+	NoLock LockStrength = ""
+	// ForUpdateLock represents FOR UPDATE.
+	ForUpdateLock LockStrength = "FOR UPDATE"
+	// ForNoKeyUpdateLock represents FOR NO KEY UPDATE.
+	ForNoKeyUpdateLock LockStrength = "FOR NO KEY UPDATE"
+	// ForShareLock represents FOR SHARE.
+	ForShareLock LockStrength = "FOR SHARE"
+	// ForKeyShareLock represents FOR KEY SHARE.
+	ForKeyShareLock LockStrength = "FOR KEY SHARE"
+)
+
+// LockWaitPolicy represents what happens when a row is already locked.
+type LockWaitPolicy string
+
+const (
+	// WaitLock waits for the lock to be released.
+	WaitLock LockWaitPolicy = ""
+	// NoWaitLock reports an error instead of waiting.
+	NoWaitLock LockWaitPolicy = "NOWAIT"
+	// SkipLockedLock skips rows that cannot be locked immediately.
+	SkipLockedLock LockWaitPolicy = "SKIP LOCKED"
+)
+
+// rowLock holds the locking clause of a SELECT query.
+type rowLock struct {
+	strength LockStrength
+	tables   []string
+	wait     LockWaitPolicy
+
+	// conflictingWait is set when both NOWAIT and SKIP LOCKED were requested.
+	conflictingWait bool
+}
+
+func (l rowLock) isZero() bool {
+	return l.strength == NoLock && len(l.tables) == 0 && l.wait == WaitLock && !l.conflictingWait
+}
+
+// setWait sets the wait policy, remembering if a different one had already been set.
+func (l rowLock) setWait(wait LockWaitPolicy) rowLock {
+	if l.wait != WaitLock && l.wait != wait {
+		l.conflictingWait = true
+	}
+	l.wait = wait
+
+	return l
+}
+
+// toSQL builds the locking clause. sources are the tables and aliases available in FROM and joins, mapped to
+// whether they are on the nullable side of an outer join, which cannot be locked.
+func (l rowLock) toSQL(state renderState, sources map[string]bool) (string, error) {
+	if l.isZero() {
+		return "", nil
+	}
+
+	if l.strength == NoLock || l.conflictingWait {
+		return "", ErrInvalidLock
+	}
+
+	if err := state.requireFeature(RowLockFeature); err != nil {
+		return "", err
+	}
+
+	if l.strength == ForNoKeyUpdateLock || l.strength == ForKeyShareLock {
+		if err := state.requireFeature(KeyRowLockFeature); err != nil {
+			return "", err
+		}
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(" ")
+	builder.WriteString(string(l.strength))
+
+	if len(l.tables) > 0 {
+		for _, table := range l.tables {
+			if nullable, ok := sources[table]; !ok || nullable {
+				return "", ErrInvalidLock
+			}
+		}
+
+		builder.WriteString(" OF ")
+		builder.WriteString(strings.Join(l.tables, ", "))
+	} else {
+		// Without OF every table is locked, including the nullable side of outer joins.
+		for _, nullable := range sources {
+			if nullable {
+				return "", ErrInvalidLock
+			}
+		}
+	}
+
+	if l.wait != WaitLock {
+		builder.WriteString(" ")
+		builder.WriteString(string(l.wait))
+	}
+
+	return builder.String(), nil
+}
+
+// tableSource returns the name a locking clause refers to a FROM or JOIN target with:
+// the alias when there is one, e.g. a for "accounts AS a", the table name otherwise.
+func tableSource(table string) (string, bool) {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	return fields[len(fields)-1], true
+}
@@ -0,0 +1,350 @@
+package sqlcraft
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	dbTag       = "db"
+	jsonTag     = "json"
+	readonlyTag = "readonly"
+	skipTag     = "-"
+)
+
+// fieldMeta describes a struct field mapped to a column.
+type fieldMeta struct {
+	column      string
+	domainField string
+	index       []int
+	readonly    bool
+}
+
+// structMeta holds the mapped fields of a struct type.
+type structMeta struct {
+	fields                 []fieldMeta
+	fieldByColumn          map[string]int
+	columns                []string
+	writableColumns        []string
+	sqlColumnByDomainField map[string]string
+}
+
+// structMetaByType caches the struct metadata per type, reflection only runs once per type.
+var structMetaByType sync.Map
+
+// Mapper maps the struct T to SQL columns using its struct tags.
+//
+// The db tag holds the column name and the json tag holds the domain field used by dafi,
+// e.g. `db:"created_at,readonly" json:"createdAt"`. Fields without a db tag or tagged with
+// db:"-" are ignored, readonly columns are selected and scanned but never inserted or updated.
+// When the json tag is missing the column name is used as domain field.
+// Embedded structs without a db tag are flattened into the parent.
+//
+// Create it with NewMapper. The zero Mapper maps no columns, its queries fail to build and
+// scanning returns ErrInvalidMapperType.
+type Mapper[T any] struct {
+	meta *structMeta
+}
+
+// metadata returns the struct metadata, which is empty for the zero Mapper.
+func (m Mapper[T]) metadata() *structMeta {
+	if m.meta == nil {
+		return &structMeta{}
+	}
+
+	return m.meta
+}
+
+// errZeroMapper returns ErrInvalidMapperType for a Mapper not created with NewMapper.
+func (m Mapper[T]) errZeroMapper() error {
+	if m.meta == nil {
+		return fmt.Errorf("%w: zero %T, use NewMapper", ErrInvalidMapperType, m)
+	}
+
+	return nil
+}
+
+// NewMapper creates a Mapper for T, which must be a struct.
+func NewMapper[T any]() (Mapper[T], error) {
+	meta, err := structMetaOf(reflect.TypeFor[T]())
+	if err != nil {
+		return Mapper[T]{}, err
+	}
+
+	return Mapper[T]{meta: meta}, nil
+}
+
+// MustNewMapper is like NewMapper but panics on error. It is intended for package level variables.
+func MustNewMapper[T any]() Mapper[T] {
+	mapper, err := NewMapper[T]()
+	if err != nil {
+		panic(err)
+	}
+
+	return mapper
+}
+
+// Columns returns every mapped column in field order.
+func (m Mapper[T]) Columns() []string {
+	return append([]string{}, m.metadata().columns...)
+}
+
+// WritableColumns returns the mapped columns that are not readonly.
+func (m Mapper[T]) WritableColumns() []string {
+	return append([]string{}, m.metadata().writableColumns...)
+}
+
+// SQLColumnByDomainField returns the mapping from domain fields to SQL columns.
+func (m Mapper[T]) SQLColumnByDomainField() map[string]string {
+	meta := m.metadata()
+
+	sqlColumnByDomainField := make(map[string]string, len(meta.sqlColumnByDomainField))
+	for field, column := range meta.sqlColumnByDomainField {
+		sqlColumnByDomainField[field] = column
+	}
+
+	return sqlColumnByDomainField
+}
+
+// Values returns the values of the writable columns of entity, in WritableColumns order.
+func (m Mapper[T]) Values(entity T) []any {
+	meta := m.metadata()
+	value := reflect.ValueOf(&entity).Elem()
+
+	values := make([]any, 0, len(meta.writableColumns))
+	for _, field := range meta.fields {
+		if field.readonly {
+			continue
+		}
+
+		fieldValue, ok := fieldByIndex(value, field.index, false)
+		if !ok {
+			values = append(values, nil)
+
+			continue
+		}
+
+		values = append(values, fieldValue.Interface())
+	}
+
+	return values
+}
+
+// Select creates a SelectQuery of every mapped column with the domain field mapping set.
+func (m Mapper[T]) Select(table string) SelectQuery {
+	return Select(m.Columns()...).From(table).SQLColumnByDomainField(m.SQLColumnByDomainField())
+}
+
+// Insert creates an InsertQuery of the writable columns with one row per entity.
+func (m Mapper[T]) Insert(table string, entities ...T) InsertQuery {
+	query := InsertInto(table).WithColumns(m.WritableColumns()...)
+	for _, entity := range entities {
+		query = query.WithValues(m.Values(entity)...)
+	}
+
+	return query
+}
+
+// Update creates an UpdateQuery setting the writable columns to the values of entity,
+// with the domain field mapping set so filters can use domain fields.
+func (m Mapper[T]) Update(table string, entity T) UpdateQuery {
+	return Update(table).
+		WithColumns(m.WritableColumns()...).
+		WithValues(m.Values(entity)...).
+		SQLColumnByDomainField(m.SQLColumnByDomainField())
+}
+
+// ScanRow scans a row selected with Columns, in the same order, into a new T.
+func (m Mapper[T]) ScanRow(row pgx.Row) (T, error) {
+	var entity T
+	if err := m.errZeroMapper(); err != nil {
+		return entity, err
+	}
+
+	value := reflect.ValueOf(&entity).Elem()
+
+	destinations := make([]any, 0, len(m.meta.fields))
+	for _, field := range m.meta.fields {
+		fieldValue, _ := fieldByIndex(value, field.index, true)
+		destinations = append(destinations, fieldValue.Addr().Interface())
+	}
+
+	if err := row.Scan(destinations...); err != nil {
+		return entity, fmt.Errorf("scan %T: %w", entity, err)
+	}
+
+	return entity, nil
+}
+
+// RowTo scans a row into a new T matching the result columns by name, so the select list can
+// be in any order or be a subset of the mapped columns. It can be used with pgx.CollectRows.
+func (m Mapper[T]) RowTo(row pgx.CollectableRow) (T, error) {
+	var entity T
+	if err := m.errZeroMapper(); err != nil {
+		return entity, err
+	}
+
+	value := reflect.ValueOf(&entity).Elem()
+
+	descriptions := row.FieldDescriptions()
+	destinations := make([]any, 0, len(descriptions))
+	for _, description := range descriptions {
+		i, ok := m.meta.fieldByColumn[description.Name]
+		if !ok {
+			return entity, fmt.Errorf("%w: %q in %T", ErrUnknownColumn, description.Name, entity)
+		}
+
+		fieldValue, _ := fieldByIndex(value, m.meta.fields[i].index, true)
+		destinations = append(destinations, fieldValue.Addr().Interface())
+	}
+
+	if err := row.Scan(destinations...); err != nil {
+		return entity, fmt.Errorf("scan %T: %w", entity, err)
+	}
+
+	return entity, nil
+}
+
+// ScanRows scans every row into a T and closes rows.
+func (m Mapper[T]) ScanRows(rows pgx.Rows) ([]T, error) {
+	if err := m.errZeroMapper(); err != nil {
+		rows.Close()
+
+		return nil, err
+	}
+
+	entities, err := pgx.CollectRows(rows, m.RowTo)
+	if err != nil {
+		return nil, fmt.Errorf("collect rows: %w", err)
+	}
+
+	return entities, nil
+}
+
+// structMetaOf returns the cached metadata of t, building it on first use.
+func structMetaOf(t reflect.Type) (*structMeta, error) {
+	if cached, ok := structMetaByType.Load(t); ok {
+		if meta, ok := cached.(*structMeta); ok {
+			return meta, nil
+		}
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMapperType, t)
+	}
+
+	meta := &structMeta{
+		fieldByColumn:          make(map[string]int),
+		sqlColumnByDomainField: make(map[string]string),
+	}
+	if err := meta.collect(t, nil); err != nil {
+		return nil, err
+	}
+
+	if len(meta.fields) == 0 {
+		return nil, fmt.Errorf("%w: %s has no db tags", ErrInvalidMapperType, t)
+	}
+
+	if cached, loaded := structMetaByType.LoadOrStore(t, meta); loaded {
+		if cachedMeta, ok := cached.(*structMeta); ok {
+			return cachedMeta, nil
+		}
+	}
+
+	return meta, nil
+}
+
+// collect adds the mapped fields of t, flattening untagged embedded structs.
+func (s *structMeta) collect(t reflect.Type, parentIndex []int) error {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		index := append(append([]int{}, parentIndex...), i)
+
+		tag, hasTag := field.Tag.Lookup(dbTag)
+		if !hasTag && field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				// Unexported embedded pointers cannot be allocated while scanning.
+				if !field.IsExported() {
+					continue
+				}
+
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				if err := s.collect(embedded, index); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		if !hasTag || tag == skipTag || !field.IsExported() {
+			continue
+		}
+
+		column, options, _ := strings.Cut(tag, ",")
+		if column == "" {
+			return fmt.Errorf("%w: %s.%s has an empty db tag", ErrInvalidFieldName, t, field.Name)
+		}
+
+		if _, ok := s.fieldByColumn[column]; ok {
+			return fmt.Errorf("%w: %q in %s", ErrDuplicateColumn, column, t)
+		}
+
+		domainField := column
+		if jsonName, _, _ := strings.Cut(field.Tag.Get(jsonTag), ","); jsonName != "" && jsonName != skipTag {
+			domainField = jsonName
+		}
+
+		if _, ok := s.sqlColumnByDomainField[domainField]; ok {
+			return fmt.Errorf("%w: %q in %s", ErrDuplicateDomainField, domainField, t)
+		}
+
+		meta := fieldMeta{
+			column:      column,
+			domainField: domainField,
+			index:       index,
+			readonly:    slices.Contains(strings.Split(options, ","), readonlyTag),
+		}
+
+		s.fieldByColumn[column] = len(s.fields)
+		s.fields = append(s.fields, meta)
+		s.columns = append(s.columns, column)
+		s.sqlColumnByDomainField[domainField] = column
+		if !meta.readonly {
+			s.writableColumns = append(s.writableColumns, column)
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndex returns the nested field of value. Nil embedded pointers are allocated when
+// allocate is true, otherwise false is returned.
+func fieldByIndex(value reflect.Value, index []int, allocate bool) (reflect.Value, bool) {
+	for i, fieldIndex := range index {
+		if i > 0 && value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				if !allocate {
+					return reflect.Value{}, false
+				}
+
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+
+			value = value.Elem()
+		}
+
+		value = value.Field(fieldIndex)
+	}
+
+	return value, true
+}
@@ -0,0 +1,276 @@
+package sqlcraft
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"backend.atomicledger.com/pkg/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditFields struct {
+	CreatedAt time.Time `db:"created_at,readonly" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+type Ownership struct {
+	OwnerID int64 `db:"owner_id" json:"ownerId"`
+}
+
+type testAccount struct {
+	auditFields
+	*Ownership
+
+	ID       int64   `db:"id,readonly" json:"id"`
+	Name     string  `db:"name" json:"name"`
+	Currency string  `db:"currency"`
+	Balance  float64 `db:"balance" json:"balance"`
+	Note     string  `db:"-" json:"note"`
+	Computed string  `json:"computed"`
+}
+
+type accountPatch struct {
+	Name        types.Optional[string] `db:"name" json:"name"`
+	Description types.Optional[string] `db:"description" json:"description"`
+	Currency    types.Optional[string] `db:"currency" json:"currency"`
+}
+
+type duplicateColumns struct {
+	auditFields
+
+	Created time.Time `db:"created_at"`
+}
+
+type duplicateDomainFields struct {
+	auditFields
+
+	Created time.Time `db:"created" json:"createdAt"`
+}
+
+type taggedOptions struct {
+	ID   int64  `db:"id,readonly,omitempty"`
+	Name string `db:"name,omitempty"`
+}
+
+// fakeRows serves fixed rows through the pgx.Rows interface.
+type fakeRows struct {
+	columns []string
+	rows    [][]any
+	current int
+}
+
+func (r *fakeRows) Close()                        {}
+func (r *fakeRows) Err() error                    { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+func (r *fakeRows) Conn() *pgx.Conn               { return nil }
+func (r *fakeRows) RawValues() [][]byte           { return nil }
+
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	descriptions := make([]pgconn.FieldDescription, 0, len(r.columns))
+	for _, column := range r.columns {
+		descriptions = append(descriptions, pgconn.FieldDescription{Name: column})
+	}
+
+	return descriptions
+}
+
+func (r *fakeRows) Next() bool {
+	r.current++
+
+	return r.current <= len(r.rows)
+}
+
+func (r *fakeRows) Values() ([]any, error) {
+	return r.rows[r.current-1], nil
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	for i, value := range r.rows[r.current-1] {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+
+	return nil
+}
+
+func TestMapper(t *testing.T) {
+	mapper, err := NewMapper[testAccount]()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"created_at", "updated_at", "owner_id", "id", "name", "currency", "balance"}, mapper.Columns())
+	assert.Equal(t, []string{"updated_at", "owner_id", "name", "currency", "balance"}, mapper.WritableColumns())
+	assert.Equal(t, map[string]string{
+		"createdAt": "created_at",
+		"updatedAt": "updated_at",
+		"ownerId":   "owner_id",
+		"id":        "id",
+		"name":      "name",
+		"currency":  "currency",
+		"balance":   "balance",
+	}, mapper.SQLColumnByDomainField())
+
+	again, err := NewMapper[testAccount]()
+	assert.NoError(t, err)
+	assert.Same(t, mapper.meta, again.meta)
+}
+
+func TestNewMapper_Errors(t *testing.T) {
+	_, err := NewMapper[int]()
+	assert.ErrorIs(t, err, ErrInvalidMapperType)
+
+	_, err = NewMapper[struct{ Name string }]()
+	assert.ErrorIs(t, err, ErrInvalidMapperType)
+
+	_, err = NewMapper[duplicateColumns]()
+	assert.ErrorIs(t, err, ErrDuplicateColumn)
+
+	_, err = NewMapper[duplicateDomainFields]()
+	assert.ErrorIs(t, err, ErrDuplicateDomainField)
+}
+
+func TestMapper_TagOptions(t *testing.T) {
+	mapper, err := NewMapper[taggedOptions]()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"id", "name"}, mapper.Columns())
+	assert.Equal(t, []string{"name"}, mapper.WritableColumns())
+}
+
+func TestMapper_Zero(t *testing.T) {
+	var mapper Mapper[testAccount]
+
+	assert.Empty(t, mapper.Columns())
+	assert.Empty(t, mapper.SQLColumnByDomainField())
+
+	_, err := mapper.Select("accounts").ToSQL()
+	assert.ErrorIs(t, err, ErrEmptyColumns)
+
+	_, err = mapper.Update("accounts", testAccount{}).ToSQL()
+	assert.ErrorIs(t, err, ErrEmptyColumns)
+
+	_, err = mapper.ScanRows(&fakeRows{columns: []string{"id"}, rows: [][]any{{int64(1)}}})
+	assert.ErrorIs(t, err, ErrInvalidMapperType)
+}
+
+func TestMapper_Queries(t *testing.T) {
+	mapper := MustNewMapper[testAccount]()
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	account := testAccount{
+		auditFields: auditFields{UpdatedAt: updatedAt},
+		Ownership:   &Ownership{OwnerID: 7},
+		ID:          1,
+		Name:        "cash",
+		Currency:    "USD",
+		Balance:     10.5,
+	}
+
+	tests := []struct {
+		name    string
+		query   Query
+		want    Result
+		wantErr bool
+	}{
+		{
+			name:  "select with domain fields",
+			query: mapper.Select("accounts").Where(dafi.Filter{Field: "ownerId", Value: 7}).OrderBy(dafi.Sort{Field: "createdAt", Type: dafi.Desc}),
+			want: Result{
+				SQL:  "SELECT created_at, updated_at, owner_id, id, name, currency, balance FROM accounts WHERE owner_id = $1 ORDER BY created_at DESC",
+				Args: []any{7},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "insert skips readonly columns",
+			query: mapper.Insert("accounts", account, testAccount{Name: "bank", Currency: "EUR"}).Returning("id"),
+			want: Result{
+				SQL:  "INSERT INTO accounts (updated_at, owner_id, name, currency, balance) VALUES ($1, $2, $3, $4, $5), ($6, $7, $8, $9, $10) RETURNING id",
+				Args: []any{updatedAt, int64(7), "cash", "USD", 10.5, time.Time{}, nil, "bank", "EUR", 0.0},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "update with domain filters",
+			query: mapper.Update("accounts", account).Where(dafi.Filter{Field: "id", Value: 1}),
+			want: Result{
+				SQL:  "UPDATE accounts SET updated_at = $1, owner_id = $2, name = $3, currency = $4, balance = $5 WHERE id = $6",
+				Args: []any{updatedAt, int64(7), "cash", "USD", 10.5, 1},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "update from patch",
+			query: MustNewMapper[accountPatch]().Update("accounts", accountPatch{Name: types.Some("savings"), Description: types.Null[string]()}).Where(dafi.Filter{Field: "name", Value: "cash"}),
+			want: Result{
+				SQL:  "UPDATE accounts SET name = $1, description = NULL WHERE name = $2",
+				Args: []any{"savings", "cash"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error unmapped domain field",
+			query:   mapper.Select("accounts").Where(dafi.Filter{Field: "note", Value: "x"}),
+			want:    Result{},
+			wantErr: true,
+		},
+	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.render(renderState{dialect: dialect}, 0)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
+}
+
+func TestMapper_Scan(t *testing.T) {
+	mapper := MustNewMapper[testAccount]()
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("scan row in column order", func(t *testing.T) {
+		rows := &fakeRows{
+			columns: mapper.Columns(),
+			rows:    [][]any{{createdAt, createdAt, int64(7), int64(1), "cash", "USD", 10.5}},
+		}
+		rows.Next()
+
+		got, err := mapper.ScanRow(rows)
+		assert.NoError(t, err)
+		assert.Equal(t, testAccount{
+			auditFields: auditFields{CreatedAt: createdAt, UpdatedAt: createdAt},
+			Ownership:   &Ownership{OwnerID: 7},
+			ID:          1,
+			Name:        "cash",
+			Currency:    "USD",
+			Balance:     10.5,
+		}, got)
+	})
+
+	t.Run("scan rows by column name", func(t *testing.T) {
+		rows := &fakeRows{
+			columns: []string{"name", "id"},
+			rows:    [][]any{{"cash", int64(1)}, {"bank", int64(2)}},
+		}
+
+		got, err := mapper.ScanRows(rows)
+		assert.NoError(t, err)
+		assert.Equal(t, []testAccount{{ID: 1, Name: "cash"}, {ID: 2, Name: "bank"}}, got)
+	})
+
+	t.Run("error unknown column", func(t *testing.T) {
+		rows := &fakeRows{
+			columns: []string{"name", "note"},
+			rows:    [][]any{{"cash", "x"}},
+		}
+
+		_, err := mapper.ScanRows(rows)
+		assert.ErrorIs(t, err, ErrUnknownColumn)
+	})
+}
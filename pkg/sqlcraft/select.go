@@ -2,7 +2,6 @@ package sqlcraft
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	"backend.atomicledger.com/pkg/dafi"
@@ -30,6 +29,7 @@ type Join struct {
 // SelectQuery represents a SELECT query.
 type SelectQuery struct {
 	table                  string
+	fromQuery              Query
 	columns                []string
 	requiredColumns        map[string]struct{}
 	sqlColumnByDomainField map[string]string
@@ -38,8 +38,18 @@ type SelectQuery struct {
 	sorts      dafi.Sorts
 	pagination dafi.Pagination
 
-	groups []string
-	joins  []Join
+	groups     []string
+	aggregates dafi.Aggregations
+	having     dafi.Filters
+	joins      []Join
+	lock       rowLock
+
+	distinctOn   []string
+	windows      []WindowExpression
+	namedWindows []namedWindow
+
+	dialect Dialect
+	lenient bool
 }
 
 // Select creates a new SelectQuery with the specified columns.
@@ -58,6 +68,14 @@ func (s SelectQuery) From(table string) SelectQuery {
 	return s
 }
 
+// FromSubquery selects from the given query aliased as alias.
+func (s SelectQuery) FromSubquery(query Query, alias string) SelectQuery {
+	s.fromQuery = query
+	s.table = alias
+
+	return s
+}
+
 // Where adds filters to the SELECT query.
 func (s SelectQuery) Where(filters ...dafi.Filter) SelectQuery {
 	s.filters = filters
@@ -72,6 +90,50 @@ func (s SelectQuery) OrderBy(sorts ...dafi.Sort) SelectQuery {
 	return s
 }
 
+// GroupBy sets the domain fields to group by.
+func (s SelectQuery) GroupBy(fields ...string) SelectQuery {
+	s.groups = fields
+
+	return s
+}
+
+// Aggregate adds aggregate expressions to the selected columns, e.g. Sum("amount").As("total").
+func (s SelectQuery) Aggregate(aggregations ...dafi.Aggregation) SelectQuery {
+	s.aggregates = append(s.aggregates, aggregations...)
+
+	return s
+}
+
+// Having adds filters on groups. Filter fields can reference aggregate aliases or domain fields.
+func (s SelectQuery) Having(filters ...dafi.Filter) SelectQuery {
+	s.having = filters
+
+	return s
+}
+
+// DistinctOn keeps only the first row of each set of rows with equal values for the domain fields.
+// When the query is ordered, the fields must lead the ORDER BY so the kept row is deterministic.
+func (s SelectQuery) DistinctOn(fields ...string) SelectQuery {
+	s.distinctOn = fields
+
+	return s
+}
+
+// Windowed adds window function expressions to the selected columns,
+// e.g. WindowAggregate(Sum("amount")).Over(NewWindow().PartitionBy("accountId").OrderBy(...)).As("balance").
+func (s SelectQuery) Windowed(expressions ...WindowExpression) SelectQuery {
+	s.windows = append(append([]WindowExpression{}, s.windows...), expressions...)
+
+	return s
+}
+
+// DefineWindow adds a named window to the WINDOW clause, referenced with NamedWindow(name).
+func (s SelectQuery) DefineWindow(name string, window Window) SelectQuery {
+	s.namedWindows = append(append([]namedWindow{}, s.namedWindows...), namedWindow{name: name, window: window})
+
+	return s
+}
+
 // Limit sets the limit for the SELECT query.
 func (s SelectQuery) Limit(limit uint) SelectQuery {
 	s.pagination.PageSize = limit
@@ -117,6 +179,56 @@ func (s SelectQuery) RightJoin(table, condition string) SelectQuery {
 	return s.addJoin(RightJoinType, table, condition)
 }
 
+// ForUpdate locks the selected rows against concurrent updates and deletes.
+func (s SelectQuery) ForUpdate() SelectQuery {
+	s.lock.strength = ForUpdateLock
+
+	return s
+}
+
+// ForNoKeyUpdate locks the selected rows like ForUpdate, but allows concurrent FOR KEY SHARE locks.
+func (s SelectQuery) ForNoKeyUpdate() SelectQuery {
+	s.lock.strength = ForNoKeyUpdateLock
+
+	return s
+}
+
+// ForShare locks the selected rows against concurrent updates while allowing other shared locks.
+func (s SelectQuery) ForShare() SelectQuery {
+	s.lock.strength = ForShareLock
+
+	return s
+}
+
+// ForKeyShare locks the selected rows against concurrent key updates and deletes.
+func (s SelectQuery) ForKeyShare() SelectQuery {
+	s.lock.strength = ForKeyShareLock
+
+	return s
+}
+
+// Of restricts the locking clause to the given tables, or their aliases when they have one.
+// Tables on the nullable side of a LEFT or RIGHT JOIN cannot be locked, so queries with outer joins must use Of.
+func (s SelectQuery) Of(tables ...string) SelectQuery {
+	s.lock.tables = tables
+
+	return s
+}
+
+// SkipLocked skips rows that are already locked instead of waiting for them.
+func (s SelectQuery) SkipLocked() SelectQuery {
+	s.lock = s.lock.setWait(SkipLockedLock)
+
+	return s
+}
+
+// NoWait fails instead of waiting when a row is already locked.
+func (s SelectQuery) NoWait() SelectQuery {
+	s.lock = s.lock.setWait(NoWaitLock)
+
+	return s
+}
+
 func (s SelectQuery) addJoin(joinType JoinType, table, condition string) SelectQuery {
 	s.joins = append(s.joins, Join{
 		Type:      joinType,
@@ -127,12 +239,78 @@ func (s SelectQuery) addJoin(joinType JoinType, table, condition string) SelectQ
 	return s
 }
 
+// Dialect sets the database the query is rendered for, Postgres by default.
+// Queries nested in it are always rendered with the dialect of the outermost query.
+func (s SelectQuery) Dialect(dialect Dialect) SelectQuery {
+	s.dialect = dialect
+
+	return s
+}
+
+// Lenient disables strict identifier validation for this query and the queries nested in it.
+// Only use it when every identifier is developer defined.
+func (s SelectQuery) Lenient() SelectQuery {
+	s.lenient = true
+
+	return s
+}
+
 // ToSQL builds the SQL query and returns the Result.
 func (s SelectQuery) ToSQL() (Result, error) {
-	if len(s.columns) == 0 {
+	return s.render(renderState{}, 0)
+}
+
+func (s SelectQuery) render(state renderState, initialArgCount int) (Result, error) {
+	state = state.nested(s.lenient, s.dialect)
+
+	if len(s.columns) == 0 && len(s.aggregates) == 0 && len(s.windows) == 0 {
 		return Result{}, ErrEmptyColumns
 	}
 
+	if len(s.having) > 0 && len(s.groups) == 0 && len(s.aggregates) == 0 {
+		return Result{}, ErrMissingGroupBy
+	}
+
+	aggregateSelects, expressionByAlias, err := buildAggregates(state, s.aggregates, s.sqlColumnByDomainField)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := s.validateTables(state); err != nil {
+		return Result{}, err
+	}
+
+	// Postgres does not allow locking rows of grouped, aggregated, distinct or windowed results.
+	if !s.lock.isZero() && (len(s.groups) > 0 || len(s.aggregates) > 0 || len(s.having) > 0 ||
+		len(s.distinctOn) > 0 || len(s.windows) > 0 || len(s.namedWindows) > 0) {
+		return Result{}, ErrInvalidLock
+	}
+
+	windowSQL, windowDefinitions, err := buildWindowClause(state, s.namedWindows, s.sqlColumnByDomainField)
+	if err != nil {
+		return Result{}, err
+	}
+
+	windowSelects := make([]string, 0, len(s.windows))
+	for _, window := range s.windows {
+		windowSQL, err := window.toSQL(state, s.sqlColumnByDomainField, windowDefinitions)
+		if err != nil {
+			return Result{}, err
+		}
+
+		windowSelects = append(windowSelects, windowSQL)
+	}
+
+	distinctSQL, err := s.buildDistinctOn(state)
+	if err != nil {
+		return Result{}, err
+	}
+
+	lockSQL, err := s.lock.toSQL(state, s.lockSources())
+	if err != nil {
+		return Result{}, err
+	}
+
 	if len(s.sqlColumnByDomainField) > 0 {
 		requiredCols := make(map[string]struct{})
 		for k := range s.requiredColumns {
@@ -150,27 +328,40 @@ func (s SelectQuery) ToSQL() (Result, error) {
 	builder := strings.Builder{}
 
 	builder.WriteString("SELECT ")
+	builder.WriteString(distinctSQL)
 
-	if len(s.requiredColumns) == 0 {
-		builder.WriteString(strings.Join(s.columns, ", "))
-	} else {
+	selectedCols := s.columns
+	if len(s.requiredColumns) > 0 {
 		// Only select the required columns.
-		selectedCols := make([]string, 0, len(s.requiredColumns))
+		requiredCols := make([]string, 0, len(s.requiredColumns))
 		for _, col := range s.columns {
 			if _, ok := s.requiredColumns[col]; ok {
-				selectedCols = append(selectedCols, col)
+				requiredCols = append(requiredCols, col)
 			}
 		}
 
-		if len(selectedCols) == 0 {
-			// Fallback to all columns if no valid required columns found.
-			builder.WriteString(strings.Join(s.columns, ", "))
-		} else {
-			builder.WriteString(strings.Join(selectedCols, ", "))
+		// Fallback to all columns if no valid required columns found.
+		if len(requiredCols) > 0 {
+			selectedCols = requiredCols
 		}
 	}
 
+	selects := append(append([]string{}, selectedCols...), aggregateSelects...)
+	builder.WriteString(strings.Join(append(selects, windowSelects...), ", "))
+
+	args := []any{}
+
 	builder.WriteString(" FROM ")
+	if s.fromQuery != nil {
+		subResult, err := renderSubquery(state, s.fromQuery, initialArgCount)
+		if err != nil {
+			return Result{}, err
+		}
+		args = append(args, subResult.Args...)
+
+		builder.WriteString(subResult.SQL)
+		builder.WriteString(" AS ")
+	}
 	builder.WriteString(s.table)
 
 	for _, join := range s.joins {
@@ -182,9 +373,8 @@ func (s SelectQuery) ToSQL() (Result, error) {
 		builder.WriteString(join.Condition)
 	}
 
-	args := []any{}
 	if len(s.filters) > 0 {
-		whereResult, err := WhereSafe(0, s.sqlColumnByDomainField, s.filters...)
+		whereResult, err := whereSafe(state, initialArgCount+len(args), s.sqlColumnByDomainField, s.filters...)
 		if err != nil {
 			return Result{}, err
 		}
@@ -194,7 +384,7 @@ func (s SelectQuery) ToSQL() (Result, error) {
 	}
 
 	if len(s.groups) > 0 {
-		groupSQL, err := BuildGroupBy(s.groups, s.sqlColumnByDomainField)
+		groupSQL, err := buildGroupBy(state, s.groups, s.sqlColumnByDomainField)
 		if err != nil {
 			return Result{}, err
 		}
@@ -202,25 +392,140 @@ func (s SelectQuery) ToSQL() (Result, error) {
 		builder.WriteString(groupSQL)
 	}
 
+	if len(s.having) > 0 {
+		havingResult, err := buildHaving(state, initialArgCount+len(args), expressionByAlias, s.sqlColumnByDomainField, s.having)
+		if err != nil {
+			return Result{}, err
+		}
+		args = append(args, havingResult.Args...)
+
+		builder.WriteString(havingResult.SQL)
+	}
+
+	builder.WriteString(windowSQL)
+
 	if len(s.sorts) > 0 {
-		sortSQL := BuildOrderBy(s.sorts, s.sqlColumnByDomainField)
+		sortSQL, err := buildOrderBy(state, s.sorts, s.sqlColumnByDomainField)
+		if err != nil {
+			return Result{}, err
+		}
 
 		builder.WriteString(sortSQL)
 	}
 
-	paginationSQL := BuildPagination(s.pagination)
+	paginationSQL := buildPagination(state.dialect, s.pagination)
 	builder.WriteString(paginationSQL)
 
+	builder.WriteString(lockSQL)
+
 	return Result{
 		SQL:  builder.String(),
 		Args: args,
 	}, nil
 }
 
+// validateTables checks the FROM table, sub-query alias and join targets in strict mode.
+func (s SelectQuery) validateTables(state renderState) error {
+	if s.fromQuery != nil {
+		if err := state.validateIdentifiers(s.table); err != nil {
+			return err
+		}
+	} else if err := state.validateTableReferences(s.table); err != nil {
+		return err
+	}
+
+	for _, join := range s.joins {
+		if err := state.validateTableReferences(join.Table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildDistinctOn builds the DISTINCT ON prefix of the select list and checks that the
+// DISTINCT ON columns match the leading ORDER BY columns, as Postgres requires.
+func (s SelectQuery) buildDistinctOn(state renderState) (string, error) {
+	if len(s.distinctOn) == 0 {
+		return "", nil
+	}
+
+	if err := state.requireFeature(DistinctOnFeature); err != nil {
+		return "", err
+	}
+
+	columns, err := resolveColumns(state, s.distinctOn, s.sqlColumnByDomainField)
+	if err != nil {
+		return "", err
+	}
+
+	remaining := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		remaining[column] = struct{}{}
+	}
+
+	for _, sort := range s.sorts {
+		if len(remaining) == 0 {
+			break
+		}
+
+		column := string(sort.Field)
+		if sqlColumn, ok := s.sqlColumnByDomainField[column]; ok {
+			column = sqlColumn
+		}
+
+		if _, ok := remaining[column]; !ok {
+			return "", ErrInvalidDistinctOn
+		}
+		delete(remaining, column)
+	}
+
+	return "DISTINCT ON (" + strings.Join(columns, ", ") + ") ", nil
+}
+
+// lockSources returns the tables and aliases a locking clause can target, mapped to whether they are
+// on the nullable side of an outer join.
+func (s SelectQuery) lockSources() map[string]bool {
+	sources := make(map[string]bool)
+
+	if s.fromQuery != nil {
+		// A sub-query is always referred to by its alias.
+		sources[s.table] = false
+	} else if source, ok := tableSource(s.table); ok {
+		sources[source] = false
+	}
+
+	for _, join := range s.joins {
+		if join.Type == RightJoinType {
+			// Every table joined so far is on the nullable side of a RIGHT JOIN.
+			for source := range sources {
+				sources[source] = true
+			}
+		}
+
+		if source, ok := tableSource(join.Table); ok {
+			sources[source] = join.Type == LeftJoinType
+		}
+	}
+
+	return sources
+}
+
 // BuildOrderBy builds the ORDER BY clause.
-func BuildOrderBy(sorts dafi.Sorts, sqlColumnByDomainField map[string]string) string {
+// In strict mode fields must be mapped when a mapping is provided, or be safe identifiers otherwise,
+// and sort types must be ASC or DESC.
+func BuildOrderBy(sorts dafi.Sorts, sqlColumnByDomainField map[string]string) (string, error) {
+	return BuildOrderByFor(Postgres, sorts, sqlColumnByDomainField)
+}
+
+// BuildOrderByFor is like BuildOrderBy but validates identifiers with the identifier quote of dialect.
+func BuildOrderByFor(dialect Dialect, sorts dafi.Sorts, sqlColumnByDomainField map[string]string) (string, error) {
+	return buildOrderBy(dialectRenderState(dialect), sorts, sqlColumnByDomainField)
+}
+
+func buildOrderBy(state renderState, sorts dafi.Sorts, sqlColumnByDomainField map[string]string) (string, error) {
 	if sorts.IsZero() {
-		return ""
+		return "", nil
 	}
 
 	builder := strings.Builder{}
@@ -232,7 +537,15 @@ func BuildOrderBy(sorts dafi.Sorts, sqlColumnByDomainField map[string]string) st
 		if len(sqlColumnByDomainField) > 0 {
 			if sqlColumn, ok := sqlColumnByDomainField[fieldName]; ok {
 				fieldName = sqlColumn
+			} else if !state.lenient {
+				return "", invalidIdentifier(fieldName)
 			}
+		} else if err := state.validateIdentifiers(fieldName); err != nil {
+			return "", err
+		}
+
+		if err := state.validateSortType(sort.Type); err != nil {
+			return "", err
 		}
 
 		builder.WriteString(fieldName)
@@ -247,11 +560,20 @@ func BuildOrderBy(sorts dafi.Sorts, sqlColumnByDomainField map[string]string) st
 		}
 	}
 
-	return builder.String()
+	return builder.String(), nil
 }
 
-// BuildPagination builds the LIMIT and OFFSET clauses.
+// BuildPagination builds the LIMIT and OFFSET clauses for Postgres.
 func BuildPagination(pagination dafi.Pagination) string {
+	return BuildPaginationFor(Postgres, pagination)
+}
+
+// BuildPaginationFor is like BuildPagination but uses the pagination syntax of dialect.
+func BuildPaginationFor(dialect Dialect, pagination dafi.Pagination) string {
+	return buildPagination(dialectRenderState(dialect).dialect, pagination)
+}
+
+func buildPagination(dialect Dialect, pagination dafi.Pagination) string {
 	if pagination.HasPageSize() && !pagination.HasPageNumber() {
 		pagination.PageNumber = 1
 	}
@@ -260,42 +582,42 @@ func BuildPagination(pagination dafi.Pagination) string {
 		return ""
 	}
 
-	builder := strings.Builder{}
-	builder.WriteString(" LIMIT ")
-	const maxInt = int(^uint(0) >> 1)
-	if pagination.PageSize > uint(maxInt) {
-		builder.WriteString(strconv.Itoa(maxInt))
-	} else {
-		builder.WriteString(strconv.Itoa(int(pagination.PageSize)))
-	}
-
-	if pagination.HasPageNumber() {
-		builder.WriteString(" OFFSET ")
-		var offset uint
-		if pagination.PageNumber > 0 {
-			offset = pagination.PageSize * (pagination.PageNumber - 1)
-		}
-		if offset > uint(maxInt) {
-			builder.WriteString(strconv.Itoa(maxInt))
-		} else {
-			builder.WriteString(strconv.Itoa(int(offset)))
-		}
+	const maxInt = uint64(^uint(0) >> 1)
+
+	limit := min(uint64(pagination.PageSize), maxInt)
+
+	var offset uint64
+	if pagination.PageNumber > 0 {
+		offset = min(uint64(pagination.PageSize*(pagination.PageNumber-1)), maxInt)
 	}
 
-	return builder.String()
+	return dialect.Pagination(limit, offset, pagination.HasPageNumber())
 }
 
 // BuildGroupBy builds the GROUP BY clause.
 func BuildGroupBy(groups []string, sqlColumnByDomainField map[string]string) (string, error) {
+	return BuildGroupByFor(Postgres, groups, sqlColumnByDomainField)
+}
+
+// BuildGroupByFor is like BuildGroupBy but validates identifiers with the identifier quote of dialect.
+func BuildGroupByFor(dialect Dialect, groups []string, sqlColumnByDomainField map[string]string) (string, error) {
+	return buildGroupBy(dialectRenderState(dialect), groups, sqlColumnByDomainField)
+}
+
+func buildGroupBy(state renderState, groups []string, sqlColumnByDomainField map[string]string) (string, error) {
 	if len(sqlColumnByDomainField) > 0 {
+		groups = append([]string{}, groups...)
+
 		for i, group := range groups {
 			sqlColumnName, ok := sqlColumnByDomainField[group]
 			if !ok {
-				return "", fmt.Errorf("invalid field name for grouping: %s", group)
+				return "", fmt.Errorf("%w for grouping: %s", ErrInvalidFieldName, group)
 			}
 
 			groups[i] = sqlColumnName
 		}
+	} else if err := state.validateIdentifiers(groups...); err != nil {
+		return "", err
 	}
 
 	return " GROUP BY " + strings.Join(groups, ", "), nil
@@ -49,6 +49,18 @@ func TestSelectQuery_ToSQL(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "select with mapped json path filter",
+			query: Select("id").From("accounts").
+				Where(dafi.Filter{Field: "meta", Path: []string{"customer", "id"}, Value: "42"}, dafi.Filter{Field: "meta", Operator: dafi.HasKey, Value: "vip"}).
+				SQLColumnByDomainField(map[string]string{"meta": "metadata"}).
+				Limit(10),
+			want: Result{
+				SQL:  "SELECT id FROM accounts WHERE metadata #>> $1 = $2 AND metadata ? $3 LIMIT 10 OFFSET 0",
+				Args: []any{[]string{"customer", "id"}, "42", "vip"},
+			},
+			wantErr: false,
+		},
 		{
 			name:  "select with filters and order by",
 			query: Select("first_name", "last_name").From("users").Where(dafi.Filter{Field: "email", Value: "hernan_rm@outlook.es"}).OrderBy(dafi.Sort{Field: "created_at"}),
@@ -85,16 +97,247 @@ func TestSelectQuery_ToSQL(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "select from sub-query",
+			query: Select("account_id", "total").
+				FromSubquery(Select("account_id", "sum(amount) AS total").From("postings").Where(dafi.Filter{Field: "currency", Value: "USD"}), "totals").
+				Where(dafi.Filter{Field: "total", Operator: dafi.Greater, Value: 1000}),
+			want: Result{
+				SQL:  "SELECT account_id, total FROM (SELECT account_id, sum(amount) AS total FROM postings WHERE currency = $1) AS totals WHERE total > $2",
+				Args: []any{"USD", 1000},
+			},
+			wantErr: false,
+		},
+		{
+			name: "select with mapped filter on sub-query renders twice",
+			query: Select("id").From("entries").Where(dafi.Filter{
+				Field:    "accountId",
+				Operator: dafi.In,
+				Value:    Subquery(Select("id").From("accounts").Where(dafi.Filter{Field: "ownerId", Value: 7}).SQLColumnByDomainField(map[string]string{"ownerId": "owner_id"})),
+			}).SQLColumnByDomainField(map[string]string{"accountId": "account_id"}),
+			want: Result{
+				SQL:  "SELECT id FROM entries WHERE account_id IN (SELECT id FROM accounts WHERE owner_id = $1)",
+				Args: []any{7},
+			},
+			wantErr: false,
+		},
+		{
+			name: "select with group by, aggregates and having on mapped fields",
+			query: Select("account_id", "currency").
+				From("postings").
+				Aggregate(Sum("amount").As("total"), Count("*").As("postings")).
+				Where(dafi.Filter{Field: "postedAt", Operator: dafi.GreaterOrEqual, Value: "2026-01-01"}).
+				GroupBy("accountId", "currency").
+				Having(dafi.Filter{Field: "total", Operator: dafi.Greater, Value: 1000}, dafi.Filter{Field: "postings", Operator: dafi.GreaterOrEqual, Value: 2}).
+				OrderBy(dafi.Sort{Field: "total", Type: dafi.Desc}).
+				SQLColumnByDomainField(map[string]string{"accountId": "account_id", "currency": "currency", "amount": "amount", "postedAt": "posted_at", "total": "total"}),
+			want: Result{
+				SQL:  "SELECT account_id, currency, SUM(amount) AS total, COUNT(*) AS postings FROM postings WHERE posted_at >= $1 GROUP BY account_id, currency HAVING SUM(amount) > $2 AND COUNT(*) >= $3 ORDER BY total DESC",
+				Args: []any{"2026-01-01", 1000, 2},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "select only aggregates",
+			query: Select().From("postings").Aggregate(CountDistinct("account_id"), Avg("amount").As("average")),
+			want: Result{
+				SQL:  "SELECT COUNT(DISTINCT account_id), AVG(amount) AS average FROM postings",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error aggregate with unknown domain field",
+			query:   Select("id").From("postings").Aggregate(Sum("secret")).SQLColumnByDomainField(map[string]string{"amount": "amount"}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error group by with unknown domain field",
+			query:   Select("id").From("postings").GroupBy("secret").SQLColumnByDomainField(map[string]string{"amount": "amount"}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error having with unknown domain field",
+			query:   Select("currency").From("postings").GroupBy("currency").Having(dafi.Filter{Field: "secret", Value: 1}).SQLColumnByDomainField(map[string]string{"currency": "currency"}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error having without group by",
+			query:   Select("currency").From("postings").Having(dafi.Filter{Field: "currency", Value: "USD"}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error invalid alias",
+			query:   Select("currency").From("postings").Aggregate(Sum("amount").As("total; DROP TABLE postings")),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:  "select for update",
+			query: Select("id", "balance").From("accounts").Where(dafi.Filter{Field: "id", Value: 1}).ForUpdate(),
+			want: Result{
+				SQL:  "SELECT id, balance FROM accounts WHERE id = $1 FOR UPDATE",
+				Args: []any{1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "claim jobs with skip locked",
+			query: Select("id", "payload").
+				From("jobs").
+				Where(dafi.Filter{Field: "status", Value: "pending"}).
+				OrderBy(dafi.Sort{Field: "created_at"}).
+				Limit(10).
+				ForNoKeyUpdate().
+				SkipLocked(),
+			want: Result{
+				SQL:  "SELECT id, payload FROM jobs WHERE status = $1 ORDER BY created_at LIMIT 10 OFFSET 0 FOR NO KEY UPDATE SKIP LOCKED",
+				Args: []any{"pending"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "lock of joined table alias with nowait",
+			query: Select("a.id", "b.amount").
+				From("accounts a").
+				InnerJoin("balances AS b", "b.account_id = a.id").
+				ForShare().
+				Of("b").
+				NoWait(),
+			want: Result{
+				SQL:  "SELECT a.id, b.amount FROM accounts a INNER JOIN balances AS b ON b.account_id = a.id FOR SHARE OF b NOWAIT",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "lock of table name",
+			query: Select("id").From("accounts").ForKeyShare().Of("accounts"),
+			want: Result{
+				SQL:  "SELECT id FROM accounts FOR KEY SHARE OF accounts",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error lock with group by",
+			query:   Select("currency").From("accounts").GroupBy("currency").ForUpdate(),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error lock with aggregates",
+			query:   Select().From("accounts").Aggregate(Count("*")).ForUpdate(),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error skip locked and nowait",
+			query:   Select("id").From("jobs").ForUpdate().SkipLocked().NoWait(),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error skip locked without lock strength",
+			query:   Select("id").From("jobs").SkipLocked(),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "lock of inner side of outer join",
+			query: Select("a.id").
+				From("accounts a").
+				LeftJoin("holds h", "h.account_id = a.id").
+				ForUpdate().
+				Of("a"),
+			want: Result{
+				SQL:  "SELECT a.id FROM accounts a LEFT JOIN holds h ON h.account_id = a.id FOR UPDATE OF a",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error lock of aliased table name",
+			query:   Select("id").From("accounts a").ForUpdate().Of("accounts"),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error lock of every table with left join",
+			query:   Select("a.id").From("accounts a").LeftJoin("holds h", "h.account_id = a.id").ForUpdate(),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error lock of nullable side of left join",
+			query:   Select("a.id").From("accounts a").LeftJoin("holds h", "h.account_id = a.id").ForShare().Of("h"),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error lock of nullable side of right join",
+			query:   Select("a.id").From("accounts a").RightJoin("holds h", "h.account_id = a.id").ForUpdate().Of("a"),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error lock of unknown table",
+			query:   Select("id").From("accounts a").ForUpdate().Of("balances"),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error unmapped sort field",
+			query:   Select("id").From("accounts").SQLColumnByDomainField(map[string]string{"name": "name"}).OrderBy(dafi.Sort{Field: "id; DROP TABLE accounts"}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error invalid sort direction",
+			query:   Select("id").From("accounts").OrderBy(dafi.Sort{Field: "id", Type: "ASC, (SELECT 1)"}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error unsafe filter field",
+			query:   Select("id").From("accounts").Where(dafi.Filter{Field: "1=1 OR id", Value: 1}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error unsafe table",
+			query:   Select("id").From("accounts; DELETE FROM accounts"),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error unsafe join table",
+			query:   Select("id").From("accounts a").InnerJoin("balances b --", "a.id = b.account_id"),
+			want:    Result{},
+			wantErr: true,
+		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.query.ToSQL()
-			if tt.wantErr {
-				assert.Error(t, err)
-				return
-			}
-			assert.NoError(t, err)
-			assert.Equal(t, tt.want, got)
-		})
-	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				query := tt.query.Dialect(dialect)
+
+				got, err := query.ToSQL()
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+
+				// Rendering must not mutate the query.
+				again, againErr := query.ToSQL()
+				assert.Equal(t, err, againErr)
+				assert.Equal(t, got, again)
+			})
+		}
+	})
 }
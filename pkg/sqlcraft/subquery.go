@@ -0,0 +1,30 @@
+package sqlcraft
+
+// SubqueryValue is a filter value rendered as a sub-query, created with Subquery.
+type SubqueryValue struct {
+	query Query
+}
+
+// Subquery wraps query so a filter compares the field with its result, e.g.
+// dafi.Filter{Field: "accountId", Operator: dafi.In, Value: Subquery(Select("id").From("accounts"))}.
+// Filter values that are not wrapped are always bound as arguments.
+func Subquery(query Query) SubqueryValue {
+	return SubqueryValue{query: query}
+}
+
+// renderSubquery renders the query wrapped in parentheses, numbering its placeholders after initialArgCount.
+func renderSubquery(state renderState, query Query, initialArgCount int) (Result, error) {
+	if query == nil {
+		return Result{}, ErrEmptyQuery
+	}
+
+	result, err := query.render(state, initialArgCount)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		SQL:  "(" + result.SQL + ")",
+		Args: result.Args,
+	}, nil
+}
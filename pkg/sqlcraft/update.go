@@ -1,12 +1,23 @@
 package sqlcraft
 
 import (
-	"strconv"
 	"strings"
 
 	"backend.atomicledger.com/pkg/dafi"
 )
 
+// ColumnRef references a column instead of binding a value, e.g. ColumnRef("s.amount")
+// sets a column from a table joined with From.
+type ColumnRef string
+
+// optionalValue is implemented by values that can be left unset or set to null, e.g. types.Optional.
+// Raw returns the underlying value, which is bound instead of the wrapper.
+type optionalValue interface {
+	IsSet() bool
+	IsNull() bool
+	Raw() any
+}
+
 // UpdateQuery represents an UPDATE query.
 type UpdateQuery struct {
 	table           string
@@ -16,8 +27,19 @@ type UpdateQuery struct {
 
 	isPartialUpdate bool
 
+	sources []joinedTable
+
 	sqlColumnByDomainField map[string]string
 	filters                dafi.Filters
+
+	dialect Dialect
+	lenient bool
+}
+
+// joinedTable represents an extra table of an UPDATE ... FROM or DELETE ... USING query.
+type joinedTable struct {
+	table     string
+	condition string
 }
 
 // Update creates a new UpdateQuery targeting the specified table.
@@ -38,6 +60,9 @@ func (u UpdateQuery) WithColumns(columns ...string) UpdateQuery {
 }
 
 // WithValues sets the values for the update.
+// Optional values (e.g. types.Optional) are skipped when unset, written as NULL when null and
+// bound as their underlying value otherwise. Partial updates never wrap them in COALESCE so a
+// PATCH can clear a nullable column.
 func (u UpdateQuery) WithValues(values ...any) UpdateQuery {
 	u.values = values
 
@@ -51,6 +76,15 @@ func (u UpdateQuery) Where(filters ...dafi.Filter) UpdateQuery {
 	return u
 }
 
+// From adds a table to the FROM clause, joined to the updated table by condition,
+// e.g. From("settlements s", "s.account_id = accounts.id").
+// The condition is developer defined and rendered as is; an empty condition adds no join condition.
+func (u UpdateQuery) From(table, condition string) UpdateQuery {
+	u.sources = append(append([]joinedTable{}, u.sources...), joinedTable{table: table, condition: condition})
+
+	return u
+}
+
 // SQLColumnByDomainField sets the mapping from domain fields to SQL columns.
 func (u UpdateQuery) SQLColumnByDomainField(sqlColumnByDomainField map[string]string) UpdateQuery {
 	u.sqlColumnByDomainField = sqlColumnByDomainField
@@ -72,47 +106,136 @@ func (u UpdateQuery) WithPartialUpdate() UpdateQuery {
 	return u
 }
 
+// Dialect sets the database the query is rendered for, Postgres by default.
+// Queries nested in it are always rendered with the dialect of the outermost query.
+func (u UpdateQuery) Dialect(dialect Dialect) UpdateQuery {
+	u.dialect = dialect
+
+	return u
+}
+
+// Lenient disables strict identifier validation for this query and the queries nested in it.
+// Only use it when every identifier is developer defined.
+func (u UpdateQuery) Lenient() UpdateQuery {
+	u.lenient = true
+
+	return u
+}
+
 // ToSQL builds the SQL query and returns the Result.
 func (u UpdateQuery) ToSQL() (Result, error) {
+	return u.render(renderState{}, 0)
+}
+
+func (u UpdateQuery) render(state renderState, initialArgCount int) (Result, error) {
+	state = state.nested(u.lenient, u.dialect)
+
 	if len(u.values) > 0 && len(u.values) != len(u.columns) {
 		return Result{}, ErrMissMatchValues
 	}
 
+	if err := state.validateTableReferences(u.table); err != nil {
+		return Result{}, err
+	}
+
+	if err := state.validateIdentifiers(u.columns...); err != nil {
+		return Result{}, err
+	}
+
+	if err := validateJoinedTables(state, u.sources); err != nil {
+		return Result{}, err
+	}
+
+	if err := state.validateReturning(u.returningValues); err != nil {
+		return Result{}, err
+	}
+
+	if len(u.returningValues) > 0 {
+		if err := state.requireFeature(ReturningFeature); err != nil {
+			return Result{}, err
+		}
+	}
+
+	if len(u.sources) > 0 {
+		if err := state.requireFeature(UpdateFromFeature); err != nil {
+			return Result{}, err
+		}
+	}
+
+	dialect := state.dialect
+
 	builder := strings.Builder{}
 
 	builder.WriteString("UPDATE ")
 	builder.WriteString(u.table)
 	builder.WriteString(" SET ")
 
+	args := []any{}
+	placeholders := 0
+	sets := 0
 	for i, column := range u.columns {
-		if u.isPartialUpdate {
-			builder.WriteString(column)
-			builder.WriteString(" = COALESCE($")
-			builder.WriteString(strconv.Itoa(i + 1))
+		value := ""
+		isOptional := false
+		if i < len(u.values) {
+			optional, ok := u.values[i].(optionalValue)
+			isOptional = ok
+
+			switch ref, isRef := u.values[i].(ColumnRef); {
+			case isOptional && !optional.IsSet():
+				continue
+			case isOptional && optional.IsNull():
+				value = "NULL"
+			case isRef:
+				if err := state.validateIdentifiers(string(ref)); err != nil {
+					return Result{}, err
+				}
+				value = string(ref)
+			case isOptional:
+				args = append(args, optional.Raw())
+			default:
+				args = append(args, u.values[i])
+			}
+		}
+
+		if value == "" {
+			placeholders++
+			value = dialect.Placeholder(initialArgCount + placeholders)
+		}
+
+		if sets > 0 {
+			builder.WriteString(", ")
+		}
+		sets++
+
+		builder.WriteString(column)
+		if u.isPartialUpdate && !isOptional {
+			builder.WriteString(" = COALESCE(")
+			builder.WriteString(value)
 			builder.WriteString(", ")
 			builder.WriteString(column)
 			builder.WriteString(")")
 		} else {
-			builder.WriteString(column)
-			builder.WriteString(" = $")
-			builder.WriteString(strconv.Itoa(i + 1))
+			builder.WriteString(" = ")
+			builder.WriteString(value)
 		}
+	}
 
-		if i < len(u.columns)-1 {
-			builder.WriteString(", ")
-		}
+	if sets == 0 {
+		return Result{}, ErrEmptyColumns
 	}
 
-	args := u.values
-	if len(u.filters) > 0 {
-		whereResult, err := WhereSafe(len(u.values), u.sqlColumnByDomainField, u.filters...)
-		if err != nil {
-			return Result{}, err
-		}
-		args = append(args, whereResult.Args...)
+	if len(u.sources) > 0 {
+		builder.WriteString(" FROM ")
+		builder.WriteString(joinedTableNames(u.sources))
+	}
 
-		builder.WriteString(whereResult.SQL)
+	whereResult, err := buildJoinedWhere(state, initialArgCount+placeholders, u.sources, u.sqlColumnByDomainField, u.filters)
+	if err != nil {
+		return Result{}, err
 	}
+	args = append(args, whereResult.Args...)
+
+	builder.WriteString(whereResult.SQL)
 
 	if len(u.returningValues) > 0 {
 		builder.WriteString(" RETURNING ")
@@ -124,3 +247,53 @@ func (u UpdateQuery) ToSQL() (Result, error) {
 		Args: args,
 	}, nil
 }
+
+// validateJoinedTables checks the FROM/USING tables in strict mode.
+func validateJoinedTables(state renderState, sources []joinedTable) error {
+	for _, source := range sources {
+		if err := state.validateTableReferences(source.table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// joinedTableNames returns the comma separated FROM/USING table list.
+func joinedTableNames(sources []joinedTable) string {
+	tables := make([]string, 0, len(sources))
+	for _, source := range sources {
+		tables = append(tables, source.table)
+	}
+
+	return strings.Join(tables, ", ")
+}
+
+// buildJoinedWhere builds the WHERE clause of an UPDATE ... FROM or DELETE ... USING query.
+// Join conditions come first; the filters are grouped so OR chaining cannot escape the join.
+func buildJoinedWhere(state renderState, initialArgCount int, sources []joinedTable, sqlColumnByDomainField map[string]string, filters dafi.Filters) (Result, error) {
+	whereResult, err := whereSafe(state, initialArgCount, sqlColumnByDomainField, filters...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	conditions := make([]string, 0, len(sources)+1)
+	for _, source := range sources {
+		if source.condition != "" {
+			conditions = append(conditions, source.condition)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return whereResult, nil
+	}
+
+	if whereResult.SQL != "" {
+		conditions = append(conditions, "("+strings.TrimPrefix(whereResult.SQL, " WHERE ")+")")
+	}
+
+	return Result{
+		SQL:  " WHERE " + strings.Join(conditions, " AND "),
+		Args: whereResult.Args,
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"backend.atomicledger.com/pkg/dafi"
+	"backend.atomicledger.com/pkg/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -86,16 +87,82 @@ func TestUpdateQuery_ToSQL(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "update from staging table",
+			query: Update("accounts a").WithColumns("balance", "settled_at", "status").WithValues(ColumnRef("s.amount"), ColumnRef("s.settled_at"), "settled").
+				From("settlement_staging s", "s.account_id = a.id").
+				Where(dafi.Filter{Field: "batch", Value: 42}, dafi.Filter{Field: "status", Value: "pending", ChainingKey: dafi.Or}, dafi.Filter{Field: "status", Value: "failed"}).
+				SQLColumnByDomainField(map[string]string{"batch": "s.batch_id", "status": "a.status"}).
+				Returning("a.id"),
+			want: Result{
+				SQL:  "UPDATE accounts a SET balance = s.amount, settled_at = s.settled_at, status = $1 FROM settlement_staging s WHERE s.account_id = a.id AND (s.batch_id = $2 AND a.status = $3 OR a.status = $4) RETURNING a.id",
+				Args: []any{"settled", 42, "pending", "failed"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "update from multiple tables with partial update",
+			query: Update("accounts a").WithColumns("balance").WithValues(ColumnRef("s.amount")).WithPartialUpdate().
+				From("settlement_staging s", "s.account_id = a.id").
+				From("batches b", "b.id = s.batch_id").
+				Where(dafi.Filter{Field: "b.status", Value: "open"}),
+			want: Result{
+				SQL:  "UPDATE accounts a SET balance = COALESCE(s.amount, balance) FROM settlement_staging s, batches b WHERE s.account_id = a.id AND b.id = s.batch_id AND (b.status = $1)",
+				Args: []any{"open"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "update optional values",
+			query: Update("accounts").WithColumns("name", "description", "credit_limit", "closed_at").
+				WithValues(types.Some("savings"), types.Null[string](), types.Optional[int64]{}, types.Null[string]()).
+				Where(dafi.Filter{Field: "id", Value: 1}),
+			want: Result{
+				SQL:  "UPDATE accounts SET name = $1, description = NULL, closed_at = NULL WHERE id = $2",
+				Args: []any{"savings", 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "partial update does not coalesce optional values",
+			query: Update("accounts").WithColumns("name", "description", "currency").
+				WithValues(types.Optional[string]{}, types.Null[string](), "USD").
+				WithPartialUpdate(),
+			want: Result{
+				SQL:  "UPDATE accounts SET description = NULL, currency = COALESCE($1, currency)",
+				Args: []any{"USD"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error every optional value unset",
+			query:   Update("accounts").WithColumns("name").WithValues(types.Optional[string]{}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error unsafe column reference",
+			query:   Update("accounts").WithColumns("balance").WithValues(ColumnRef("0; DROP TABLE accounts")),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error unsafe from table",
+			query:   Update("accounts").WithColumns("balance").WithValues(1).From("staging; --", ""),
+			want:    Result{},
+			wantErr: true,
+		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.query.ToSQL()
-			if tt.wantErr {
-				assert.Error(t, err)
-				return
-			}
-			assert.NoError(t, err)
-			assert.Equal(t, tt.want, got)
-		})
-	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.render(renderState{dialect: dialect}, 0)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
 }
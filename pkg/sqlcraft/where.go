@@ -2,12 +2,16 @@ package sqlcraft
 
 import (
 	"fmt"
-	"strconv"
+	"reflect"
+	"regexp"
 	"strings"
 
 	"backend.atomicledger.com/pkg/dafi"
 )
 
+// numericText matches decimal numbers as accepted by the Postgres numeric input, e.g. 100, -0.5 or 1e3.
+var numericText = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
 var psqlOperatorByDafiOperator = map[dafi.FilterOperator]string{
 	dafi.Equal:          "=",
 	dafi.NotEqual:       "<>",
@@ -23,34 +27,107 @@ var psqlOperatorByDafiOperator = map[dafi.FilterOperator]string{
 	dafi.IsNotNull:      "IS NOT NULL",
 	dafi.In:             "IN",
 	dafi.NotIn:          "NOT IN",
-	dafi.Default:        "",
+	dafi.Exists:         "EXISTS",
+	dafi.NotExists:      "NOT EXISTS",
+	dafi.Default:        "=",
+	dafi.JSONContains:   "@>",
+	dafi.HasKey:         "?",
+	dafi.HasAnyKey:      "?|",
+	dafi.HasAllKeys:     "?&",
+	dafi.JSONPathMatch:  "@@",
+}
+
+// conditionClause describes the filter clause rendered by buildConditions.
+type conditionClause struct {
+	keyword string
+	// allowExcluded renders Excluded values as EXCLUDED references, which are only valid in ON CONFLICT DO UPDATE.
+	allowExcluded bool
 }
 
+var (
+	whereClause         = conditionClause{keyword: "WHERE"}
+	havingClause        = conditionClause{keyword: "HAVING"}
+	conflictWhereClause = conditionClause{keyword: "WHERE", allowExcluded: true}
+)
+
 // WhereSafe maps domain field names to sql column names.
 // if a filter with an unknow domain field name is found it will return an error.
+// Without a mapping, every filter field must be a safe identifier.
 func WhereSafe(initialArgCount int, sqlColumnByDomainField map[string]string, filters ...dafi.Filter) (Result, error) {
-	if len(sqlColumnByDomainField) > 0 {
-		for i, filter := range filters {
-			sqlColumnName, ok := sqlColumnByDomainField[string(filter.Field)]
-			if !ok {
-				return Result{}, ErrInvalidFieldName
+	return WhereSafeFor(Postgres, initialArgCount, sqlColumnByDomainField, filters...)
+}
+
+// WhereSafeFor is like WhereSafe but renders the clause for dialect.
+func WhereSafeFor(dialect Dialect, initialArgCount int, sqlColumnByDomainField map[string]string, filters ...dafi.Filter) (Result, error) {
+	return whereSafe(dialectRenderState(dialect), initialArgCount, sqlColumnByDomainField, filters...)
+}
+
+func whereSafe(state renderState, initialArgCount int, sqlColumnByDomainField map[string]string, filters ...dafi.Filter) (Result, error) {
+	filters, err := mapFilterFields(state, sqlColumnByDomainField, filters)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return buildConditions(state, whereClause, initialArgCount, filters...)
+}
+
+// mapFilterFields converts the filter fields to sql column names, or checks them in strict mode
+// when there is no mapping. Fields of EXISTS filters are ignored.
+func mapFilterFields(state renderState, sqlColumnByDomainField map[string]string, filters dafi.Filters) (dafi.Filters, error) {
+	if len(sqlColumnByDomainField) == 0 {
+		for _, filter := range filters {
+			if filter.Operator == dafi.Exists || filter.Operator == dafi.NotExists {
+				continue
+			}
+
+			if err := state.validateIdentifiers(string(filter.Field)); err != nil {
+				return nil, err
 			}
-			filters[i].Field = dafi.FilterField(sqlColumnName)
 		}
+
+		return filters, nil
 	}
 
-	return Where(initialArgCount, filters...)
+	// Copy the filters so a query can be rendered more than once, e.g. when nested as a sub-query.
+	filters = append(dafi.Filters{}, filters...)
+
+	for i, filter := range filters {
+		if filter.Operator == dafi.Exists || filter.Operator == dafi.NotExists {
+			continue
+		}
+
+		sqlColumnName, ok := sqlColumnByDomainField[string(filter.Field)]
+		if !ok {
+			return nil, ErrInvalidFieldName
+		}
+		filters[i].Field = dafi.FilterField(sqlColumnName)
+	}
+
+	return filters, nil
 }
 
-// Where builds the WHERE clause.
+// Where builds the WHERE clause. Every filter field must be a safe identifier, use WhereSafe to map domain fields.
 func Where(initialArgCount int, filters ...dafi.Filter) (Result, error) {
+	return WhereFor(Postgres, initialArgCount, filters...)
+}
+
+// WhereFor is like Where but renders the clause for dialect, e.g. with ? placeholders for MySQL.
+func WhereFor(dialect Dialect, initialArgCount int, filters ...dafi.Filter) (Result, error) {
+	return whereSafe(dialectRenderState(dialect), initialArgCount, nil, filters...)
+}
+
+// buildConditions builds a filter clause introduced by the clause keyword (WHERE or HAVING).
+func buildConditions(state renderState, clause conditionClause, initialArgCount int, filters ...dafi.Filter) (Result, error) {
 	if len(filters) == 0 {
 		return Result{}, nil
 	}
 
 	builder := strings.Builder{}
-	builder.WriteString(" WHERE ")
+	builder.WriteString(" ")
+	builder.WriteString(clause.keyword)
+	builder.WriteString(" ")
 
+	dialect := state.dialect
 	args := []any{}
 	argCount := initialArgCount
 
@@ -67,40 +144,81 @@ func Where(initialArgCount int, filters ...dafi.Filter) (Result, error) {
 			operator = dafi.Equal
 		}
 
-		switch operator {
-		case dafi.IsNull, dafi.IsNotNull:
-			builder.WriteString(string(filter.Field))
+		column := string(filter.Field)
+		value := filter.Value
+
+		if filter.IsJSON() {
+			if err := state.requireFeature(JSONFeature); err != nil {
+				return Result{}, err
+			}
+
+			jsonResult, err := jsonColumn(dialect, column, filter.Path, operator, jsonCast(filter, operator), argCount)
+			if err != nil {
+				return Result{}, err
+			}
+			column = jsonResult.SQL
+
+			args = append(args, jsonResult.Args...)
+			argCount += len(jsonResult.Args)
+
+			value = jsonValue(filter, operator)
+		}
+
+		if _, ok := value.(Excluded); ok && !clause.allowExcluded {
+			return Result{}, ErrInvalidExcluded
+		}
+
+		subquery, isSubquery := value.(SubqueryValue)
+
+		switch {
+		case isSubquery:
+			// Sub-queries number their placeholders after the arguments bound so far.
+			subResult, err := whereSubquery(state, dafi.FilterField(column), operator, subquery.query, argCount)
+			if err != nil {
+				return Result{}, err
+			}
+			builder.WriteString(subResult.SQL)
+
+			args = append(args, subResult.Args...)
+			argCount += len(subResult.Args)
+		case operator == dafi.Exists, operator == dafi.NotExists, operator == dafi.Default:
+			// These operators require a sub-query value.
+			return Result{}, ErrInvalidOperator
+		case operator == dafi.IsNull, operator == dafi.IsNotNull:
+			builder.WriteString(column)
 			builder.WriteString(" ")
 			builder.WriteString(psqlOperatorByDafiOperator[operator])
-		case dafi.In, dafi.NotIn:
-			builder.WriteString(string(filter.Field))
+		case operator == dafi.In, operator == dafi.NotIn:
+			builder.WriteString(column)
 			builder.WriteString(" ")
 			builder.WriteString(psqlOperatorByDafiOperator[operator])
 			builder.WriteString(" ")
 
-			inResult := In(filter.Value, argCount+1)
+			inResult := in(dialect, value, argCount+1)
 			builder.WriteString(inResult.SQL)
 			args = append(args, inResult.Args...)
 			argCount += len(inResult.Args)
-		case dafi.Contains, dafi.NotContains:
-			builder.WriteString(string(filter.Field))
-			builder.WriteString(" ")
-			builder.WriteString(psqlOperatorByDafiOperator[operator])
-			builder.WriteString(" ")
-			builder.WriteString("$")
-			builder.WriteString(strconv.Itoa(argCount + 1))
+		case operator == dafi.Contains, operator == dafi.NotContains:
+			builder.WriteString(dialect.CaseInsensitiveMatch(column, dialect.Placeholder(argCount+1), operator == dafi.NotContains))
 
-			args = append(args, fmt.Sprintf("%%%v%%", filter.Value))
+			args = append(args, fmt.Sprintf("%%%v%%", value))
 			argCount++
 		default:
-			builder.WriteString(string(filter.Field))
+			builder.WriteString(column)
 			builder.WriteString(" ")
 			builder.WriteString(psqlOperatorByDafiOperator[operator])
 			builder.WriteString(" ")
-			builder.WriteString("$")
-			builder.WriteString(strconv.Itoa(argCount + 1))
 
-			args = append(args, filter.Value)
+			// EXCLUDED references are column references, not values.
+			if excluded, ok := value.(Excluded); ok {
+				builder.WriteString(excluded.String())
+
+				break
+			}
+
+			builder.WriteString(dialect.Placeholder(argCount + 1))
+
+			args = append(args, value)
 			argCount++
 		}
 
@@ -110,12 +228,18 @@ func Where(initialArgCount int, filters ...dafi.Filter) (Result, error) {
 			}
 		}
 
+		// The chaining key is written into the SQL text, only AND and OR are accepted.
+		chainingKey := dafi.FilterChainingKey(strings.ToUpper(string(filter.ChainingKey)))
+		switch chainingKey {
+		case "":
+			chainingKey = dafi.And // Default to AND.
+		case dafi.And, dafi.Or:
+		default:
+			return Result{}, fmt.Errorf("%w: chaining key %q", ErrInvalidOperator, filter.ChainingKey)
+		}
+
 		// Add chaining key for all but the last filter.
 		if i < len(filters)-1 {
-			chainingKey := filter.ChainingKey
-			if chainingKey == "" {
-				chainingKey = dafi.And // Default to AND.
-			}
 			builder.WriteString(" ")
 			builder.WriteString(string(chainingKey))
 			builder.WriteString(" ")
@@ -127,3 +251,152 @@ func Where(initialArgCount int, filters ...dafi.Filter) (Result, error) {
 		Args: args,
 	}, nil
 }
+
+// whereSubquery renders a filter whose value is a sub-query.
+// dafi.Default compares the field with the scalar result of the sub-query using equality.
+func whereSubquery(state renderState, field dafi.FilterField, operator dafi.FilterOperator, query Query, argCount int) (Result, error) {
+	sqlOperator, ok := psqlOperatorByDafiOperator[operator]
+	if !ok {
+		return Result{}, ErrInvalidOperator
+	}
+
+	switch operator {
+	case dafi.IsNull, dafi.IsNotNull, dafi.Is, dafi.IsNot, dafi.Contains, dafi.NotContains:
+		return Result{}, ErrInvalidOperator
+	}
+
+	subResult, err := renderSubquery(state, query, argCount)
+	if err != nil {
+		return Result{}, err
+	}
+
+	builder := strings.Builder{}
+	if operator != dafi.Exists && operator != dafi.NotExists {
+		builder.WriteString(string(field))
+		builder.WriteString(" ")
+	}
+	builder.WriteString(sqlOperator)
+	builder.WriteString(" ")
+	builder.WriteString(subResult.SQL)
+
+	return Result{
+		SQL:  builder.String(),
+		Args: subResult.Args,
+	}, nil
+}
+
+// jsonColumn renders the JSON path of column as a bound text array.
+// JSON operators compare the jsonb value at the path (#>), other operators compare it as text (#>>)
+// converted with cast, if any.
+func jsonColumn(dialect Dialect, column string, path []string, operator dafi.FilterOperator, cast string, argCount int) (Result, error) {
+	if len(path) == 0 {
+		return Result{SQL: column}, nil
+	}
+
+	if len(path) > dafi.MaxJSONPathDepth {
+		return Result{}, fmt.Errorf("%w: %d segments exceed %d", ErrInvalidJSONPath, len(path), dafi.MaxJSONPathDepth)
+	}
+
+	for _, segment := range path {
+		if !dafi.IsJSONPathSegment(segment) {
+			return Result{}, fmt.Errorf("%w: %q", ErrInvalidJSONPath, segment)
+		}
+	}
+
+	pathOperator := " #>> "
+	if operator.IsJSON() {
+		pathOperator = " #> "
+	}
+
+	sql := column + pathOperator + dialect.Placeholder(argCount+1)
+	if cast != "" {
+		sql = "(" + sql + ")::" + cast
+	}
+
+	return Result{
+		SQL:  sql,
+		Args: []any{append([]string{}, path...)},
+	}, nil
+}
+
+// jsonCast returns the type the text at a JSON path is cast to before it is compared with the filter value.
+// Numbers and booleans, or slices of them for IN, compare by value, otherwise "9" > "100" as text.
+// Strings compare as text, except that ordering operators compare strings holding a number, such as the
+// values parsed from a URL, numerically.
+func jsonCast(filter dafi.Filter, operator dafi.FilterOperator) string {
+	if len(filter.Path) == 0 || filter.Value == nil {
+		return ""
+	}
+
+	valueType := reflect.TypeOf(filter.Value)
+
+	switch operator {
+	case dafi.Greater, dafi.GreaterOrEqual, dafi.Less, dafi.LessOrEqual:
+		if text, ok := filter.Value.(string); ok && numericText.MatchString(text) {
+			return "numeric"
+		}
+	case dafi.Equal, dafi.NotEqual, dafi.Is, dafi.IsNot:
+	case dafi.In, dafi.NotIn:
+		if valueType.Kind() != reflect.Slice {
+			return ""
+		}
+
+		valueType = valueType.Elem()
+	default:
+		return ""
+	}
+
+	switch valueType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "numeric"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return ""
+	}
+}
+
+// jsonValue converts the filter value to the type bound for the JSON operator.
+// Keys for ?| and ?& are bound as a text array and values compared with #>> are bound as text,
+// unless jsonCast converts the path to their type.
+func jsonValue(filter dafi.Filter, operator dafi.FilterOperator) any {
+	switch {
+	case operator == dafi.HasAnyKey, operator == dafi.HasAllKeys:
+		return jsonTexts(filter.Value)
+	case len(filter.Path) == 0, operator.IsJSON(), jsonCast(filter, operator) != "":
+		return filter.Value
+	case operator == dafi.In, operator == dafi.NotIn:
+		return jsonTexts(filter.Value)
+	}
+
+	switch value := filter.Value.(type) {
+	case nil, string, SubqueryValue, Excluded:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// jsonTexts converts a slice or a comma separated string to a text array.
+func jsonTexts(value any) any {
+	switch texts := value.(type) {
+	case string:
+		return strings.Split(texts, ",")
+	case []string:
+		return texts
+	}
+
+	slice := reflect.ValueOf(value)
+	if slice.Kind() != reflect.Slice {
+		return value
+	}
+
+	texts := make([]string, 0, slice.Len())
+	for i := range slice.Len() {
+		texts = append(texts, fmt.Sprint(slice.Index(i).Interface()))
+	}
+
+	return texts
+}
@@ -1,6 +1,7 @@
 package sqlcraft
 
 import (
+	"net/url"
 	"testing"
 
 	"backend.atomicledger.com/pkg/dafi"
@@ -235,16 +236,330 @@ func TestWhere(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "in sub-query",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Field:    "currency",
+						Operator: dafi.Equal,
+						Value:    "USD",
+					},
+					dafi.Filter{
+						Field:    "account_id",
+						Operator: dafi.In,
+						Value:    Subquery(Select("id").From("accounts").Where(dafi.Filter{Field: "type", Value: "expense"})),
+					},
+					dafi.Filter{
+						Field:    "amount",
+						Operator: dafi.Greater,
+						Value:    100,
+					},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE currency = $1 AND account_id IN (SELECT id FROM accounts WHERE type = $2) AND amount > $3",
+				Args: []any{"USD", "expense", 100},
+			},
+			wantErr: false,
+		},
+		{
+			name: "exists and not exists sub-queries",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Operator: dafi.Exists,
+						Value:    Subquery(Select("1").From("postings p").Where(dafi.Filter{Field: "p.amount", Operator: dafi.Greater, Value: 500})),
+					},
+					dafi.Filter{
+						Operator: dafi.NotExists,
+						Value:    Subquery(Select("1").From("attachments")),
+					},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE EXISTS (SELECT 1 FROM postings p WHERE p.amount > $1) AND NOT EXISTS (SELECT 1 FROM attachments)",
+				Args: []any{500},
+			},
+			wantErr: false,
+		},
+		{
+			name: "default operator compares with a scalar sub-query",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Field:    "amount",
+						Operator: dafi.Default,
+						Value:    Subquery(Select("max(amount)").From("postings")),
+					},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE amount = (SELECT max(amount) FROM postings)",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "default operator without sub-query",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Field:    "amount",
+						Operator: dafi.Default,
+						Value:    "IN (SELECT 1)",
+					},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "exists without sub-query",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Operator: dafi.Exists,
+						Value:    "SELECT 1",
+					},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "unsafe field",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Field:    "id = 1 OR 1",
+						Operator: dafi.Equal,
+						Value:    1,
+					},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "excluded reference outside on conflict",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Field:    "version",
+						Operator: dafi.Less,
+						Value:    Excluded("version"),
+					},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "sub-query with invalid operator",
+			args: args{
+				filters: dafi.Filters{
+					dafi.Filter{
+						Field:    "name",
+						Operator: dafi.Contains,
+						Value:    Subquery(Select("name").From("accounts")),
+					},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "json path equality",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Path: []string{"customer", "id"}, Operator: dafi.Equal, Value: 42},
+					{Field: "status", Operator: dafi.Equal, Value: "settled"},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE (meta #>> $1)::numeric = $2 AND status = $3",
+				Args: []any{[]string{"customer", "id"}, 42, "settled"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "json path in and null checks",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Path: []string{"tier"}, Operator: dafi.In, Value: []int{1, 2}},
+					{Field: "meta", Path: []string{"closedAt"}, Operator: dafi.IsNull},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE (meta #>> $1)::numeric IN ($2, $3) AND meta #>> $4 IS NULL",
+				Args: []any{[]string{"tier"}, 1, 2, []string{"closedAt"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "json path numeric ordering",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Path: []string{"limits", "daily"}, Operator: dafi.Greater, Value: 9},
+					{Field: "meta", Path: []string{"fee"}, Operator: dafi.LessOrEqual, Value: 100.5},
+					{Field: "meta", Path: []string{"vip"}, Operator: dafi.Equal, Value: true},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE (meta #>> $1)::numeric > $2 AND (meta #>> $3)::numeric <= $4 AND (meta #>> $5)::boolean = $6",
+				Args: []any{[]string{"limits", "daily"}, 9, []string{"fee"}, 100.5, []string{"vip"}, true},
+			},
+			wantErr: false,
+		},
+		{
+			name: "json path numeric text ordering",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Path: []string{"amount"}, Operator: dafi.Greater, Value: "100"},
+					{Field: "meta", Path: []string{"rate"}, Operator: dafi.Less, Value: "-0.5"},
+					{Field: "meta", Path: []string{"code"}, Operator: dafi.Equal, Value: "007"},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE (meta #>> $1)::numeric > $2 AND (meta #>> $3)::numeric < $4 AND meta #>> $5 = $6",
+				Args: []any{[]string{"amount"}, "100", []string{"rate"}, "-0.5", []string{"code"}, "007"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "json path text comparison",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Path: []string{"settledAt"}, Operator: dafi.GreaterOrEqual, Value: "2024-01-01"},
+					{Field: "meta", Path: []string{"tier"}, Operator: dafi.In, Value: []string{"gold", "silver"}},
+					{Field: "meta", Path: []string{"code"}, Operator: dafi.Contains, Value: 42},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE meta #>> $1 >= $2 AND meta #>> $3 IN ($4, $5) AND meta #>> $6 ILIKE $7",
+				Args: []any{[]string{"settledAt"}, "2024-01-01", []string{"tier"}, "gold", "silver", []string{"code"}, "%42%"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "json containment",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Operator: dafi.JSONContains, Value: `{"tier":"gold"}`},
+					{Field: "meta", Path: []string{"limits"}, Operator: dafi.JSONContains, Value: map[string]int{"daily": 100}},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE meta @> $1 AND meta #> $2 @> $3",
+				Args: []any{`{"tier":"gold"}`, []string{"limits"}, map[string]int{"daily": 100}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "json key existence",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Operator: dafi.HasKey, Value: "refund", ChainingKey: dafi.Or},
+					{Field: "meta", Operator: dafi.HasAnyKey, Value: "vip,partner", ChainingKey: dafi.Or},
+					{Field: "meta", Path: []string{"tax"}, Operator: dafi.HasAllKeys, Value: []any{"rate", "region"}},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE meta ? $1 OR meta ?| $2 OR meta #> $3 ?& $4",
+				Args: []any{"refund", []string{"vip", "partner"}, []string{"tax"}, []string{"rate", "region"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "jsonpath predicate",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Operator: dafi.JSONPathMatch, Value: "$.amount > 100"},
+				},
+			},
+			want: Result{
+				SQL:  " WHERE meta @@ $1",
+				Args: []any{"$.amount > 100"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "error invalid chaining key",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "amount", Value: 5, ChainingKey: "OR 1=1 OR"},
+					{Field: "name", Value: "cash"},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error invalid chaining key on last filter",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "amount", Value: 5, ChainingKey: "; DELETE FROM t; --"},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error invalid json path segment",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Path: []string{"customer'; DROP TABLE accounts; --"}, Operator: dafi.Equal, Value: 42},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error empty json path segment",
+			args: args{
+				filters: dafi.Filters{
+					{Field: "meta", Path: []string{"customer", ""}, Operator: dafi.Equal, Value: 42},
+				},
+			},
+			want:    Result{},
+			wantErr: true,
+		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := Where(0, tt.args.filters...)
-			if tt.wantErr {
-				assert.Error(t, err)
-				return
-			}
-			assert.NoError(t, err)
-			assert.Equal(t, tt.want, got)
-		})
-	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := WhereFor(dialect, 0, tt.args.filters...)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
+}
+
+func TestWhere_UnwrappedQueryIsBound(t *testing.T) {
+	subquery := Select("id").From("accounts")
+
+	got, err := Where(0, dafi.Filter{Field: "id", Value: subquery})
+	assert.NoError(t, err)
+	assert.Equal(t, Result{SQL: " WHERE id = $1", Args: []any{subquery}}, got)
+}
+
+func TestWhere_ParsedJSONPath(t *testing.T) {
+	criteria, err := dafi.NewQueryParser().WithJSONFields("meta").Parse(url.Values{
+		"meta.amount":     []string{"gt:100"},
+		"meta.settledAt":  []string{"lte:2024-01-01"},
+		"meta.customerId": []string{"eq:42"},
+	})
+	assert.NoError(t, err)
+
+	got, err := Where(0, criteria.Filters...)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{
+		SQL:  " WHERE (meta #>> $1)::numeric > $2 AND meta #>> $3 = $4 AND meta #>> $5 <= $6",
+		Args: []any{[]string{"amount"}, "100", []string{"customerId"}, "42", []string{"settledAt"}, "2024-01-01"},
+	}, got)
 }
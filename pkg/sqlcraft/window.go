@@ -0,0 +1,462 @@
+package sqlcraft
+
+import (
+	"strconv"
+	"strings"
+
+	"backend.atomicledger.com/pkg/dafi"
+)
+
+// WindowFunction represents a function evaluated over a window.
+type WindowFunction string
+
+const (
+	// RowNumberFunction represents ROW_NUMBER().
+	RowNumberFunction WindowFunction = "ROW_NUMBER"
+	// RankFunction represents RANK().
+	RankFunction WindowFunction = "RANK"
+	// DenseRankFunction represents DENSE_RANK().
+	DenseRankFunction WindowFunction = "DENSE_RANK"
+	// LagFunction represents LAG(field[, offset]).
+	LagFunction WindowFunction = "LAG"
+	// LeadFunction represents LEAD(field[, offset]).
+	LeadFunction WindowFunction = "LEAD"
+	// FirstValueFunction represents FIRST_VALUE(field).
+	FirstValueFunction WindowFunction = "FIRST_VALUE"
+	// LastValueFunction represents LAST_VALUE(field).
+	LastValueFunction WindowFunction = "LAST_VALUE"
+)
+
+// FrameMode represents the unit of a window frame.
+type FrameMode string
+
+const (
+	// RowsFrame counts the frame in physical rows.
+	RowsFrame FrameMode = "ROWS"
+	// RangeFrame counts the frame in peer groups or in values of the ORDER BY column.
+	RangeFrame FrameMode = "RANGE"
+)
+
+// frameBoundKind is ordered from the start to the end of a partition.
+type frameBoundKind int
+
+const (
+	unboundedPrecedingBound frameBoundKind = iota + 1
+	precedingBound
+	currentRowBound
+	followingBound
+	unboundedFollowingBound
+)
+
+// FrameBound represents the start or end of a window frame.
+type FrameBound struct {
+	kind   frameBoundKind
+	offset uint
+}
+
+// UnboundedPreceding bounds the frame at the first row of the partition.
+func UnboundedPreceding() FrameBound {
+	return FrameBound{kind: unboundedPrecedingBound}
+}
+
+// Preceding bounds the frame offset rows (or values in RANGE mode) before the current row.
+func Preceding(offset uint) FrameBound {
+	return FrameBound{kind: precedingBound, offset: offset}
+}
+
+// CurrentRow bounds the frame at the current row.
+func CurrentRow() FrameBound {
+	return FrameBound{kind: currentRowBound}
+}
+
+// Following bounds the frame offset rows (or values in RANGE mode) after the current row.
+func Following(offset uint) FrameBound {
+	return FrameBound{kind: followingBound, offset: offset}
+}
+
+// UnboundedFollowing bounds the frame at the last row of the partition.
+func UnboundedFollowing() FrameBound {
+	return FrameBound{kind: unboundedFollowingBound}
+}
+
+func (b FrameBound) hasOffset() bool {
+	return b.kind == precedingBound || b.kind == followingBound
+}
+
+func (b FrameBound) String() string {
+	switch b.kind {
+	case unboundedPrecedingBound:
+		return "UNBOUNDED PRECEDING"
+	case precedingBound:
+		return strconv.FormatUint(uint64(b.offset), 10) + " PRECEDING"
+	case currentRowBound:
+		return "CURRENT ROW"
+	case followingBound:
+		return strconv.FormatUint(uint64(b.offset), 10) + " FOLLOWING"
+	case unboundedFollowingBound:
+		return "UNBOUNDED FOLLOWING"
+	default:
+		return ""
+	}
+}
+
+// frame represents a ROWS/RANGE BETWEEN start AND end clause.
+type frame struct {
+	mode  FrameMode
+	start FrameBound
+	end   FrameBound
+}
+
+// Window represents a window specification: the partitions, their order and the frame.
+type Window struct {
+	base        string
+	partitionBy []string
+	orderBy     dafi.Sorts
+	frame       frame
+}
+
+// NewWindow creates an empty window specification, i.e. the whole result set.
+func NewWindow() Window {
+	return Window{}
+}
+
+// NamedWindow references a window defined with SelectQuery.DefineWindow.
+// Ordering can still be added when the named window does not define it, and a frame when the named
+// window does not define one: Postgres cannot copy a window with a frame, it can only be referenced as is.
+func NamedWindow(name string) Window {
+	return Window{base: name}
+}
+
+// PartitionBy sets the domain fields that split the rows into partitions.
+func (w Window) PartitionBy(fields ...string) Window {
+	w.partitionBy = fields
+
+	return w
+}
+
+// OrderBy sets the order of the rows within each partition.
+func (w Window) OrderBy(sorts ...dafi.Sort) Window {
+	w.orderBy = sorts
+
+	return w
+}
+
+// Rows sets a ROWS BETWEEN start AND end frame.
+func (w Window) Rows(start, end FrameBound) Window {
+	w.frame = frame{mode: RowsFrame, start: start, end: end}
+
+	return w
+}
+
+// Range sets a RANGE BETWEEN start AND end frame.
+func (w Window) Range(start, end FrameBound) Window {
+	w.frame = frame{mode: RangeFrame, start: start, end: end}
+
+	return w
+}
+
+// isReference reports whether the window only references a named window, rendered as OVER name.
+func (w Window) isReference() bool {
+	return w.base != "" && len(w.partitionBy) == 0 && len(w.orderBy) == 0 && w.frame.mode == ""
+}
+
+// windowDefinition describes what a window based on a named window inherits from it.
+type windowDefinition struct {
+	orderByCount int
+	hasFrame     bool
+}
+
+// resolve checks the window against the named window it is based on and returns its ORDER BY and frame,
+// including the inherited ones. definitions holds the named windows the window can be based on.
+func (w Window) resolve(definitions map[string]windowDefinition) (windowDefinition, error) {
+	definition := windowDefinition{orderByCount: len(w.orderBy), hasFrame: w.frame.mode != ""}
+	if w.base == "" {
+		return definition, nil
+	}
+
+	if !isIdentifier(w.base) {
+		return windowDefinition{}, invalidIdentifier(w.base)
+	}
+
+	base, ok := definitions[w.base]
+	if !ok {
+		return windowDefinition{}, ErrInvalidWindow
+	}
+
+	if w.isReference() {
+		return base, nil
+	}
+
+	// Postgres does not allow overriding the partitioning or the ordering of a named window,
+	// nor copying a named window with a frame.
+	if len(w.partitionBy) > 0 || (len(w.orderBy) > 0 && base.orderByCount > 0) || base.hasFrame {
+		return windowDefinition{}, ErrInvalidWindow
+	}
+
+	if definition.orderByCount == 0 {
+		definition.orderByCount = base.orderByCount
+	}
+
+	return definition, nil
+}
+
+// toSQL builds the window specification without the surrounding parentheses and returns its definition.
+// definitions holds the named windows the window can be based on.
+func (w Window) toSQL(state renderState, sqlColumnByDomainField map[string]string, definitions map[string]windowDefinition) (string, windowDefinition, error) {
+	definition, err := w.resolve(definitions)
+	if err != nil {
+		return "", windowDefinition{}, err
+	}
+
+	parts := make([]string, 0, 4)
+
+	if w.base != "" {
+		parts = append(parts, w.base)
+	}
+
+	if len(w.partitionBy) > 0 {
+		columns, err := resolveColumns(state, w.partitionBy, sqlColumnByDomainField)
+		if err != nil {
+			return "", windowDefinition{}, err
+		}
+
+		parts = append(parts, "PARTITION BY "+strings.Join(columns, ", "))
+	}
+
+	if len(w.orderBy) > 0 {
+		orderSQL, err := buildOrderBy(state, w.orderBy, sqlColumnByDomainField)
+		if err != nil {
+			return "", windowDefinition{}, err
+		}
+
+		parts = append(parts, strings.TrimPrefix(orderSQL, " "))
+	}
+
+	if w.frame.mode != "" {
+		frameSQL, err := w.frame.toSQL(definition.orderByCount)
+		if err != nil {
+			return "", windowDefinition{}, err
+		}
+
+		parts = append(parts, frameSQL)
+	}
+
+	return strings.Join(parts, " "), definition, nil
+}
+
+// toSQL builds the frame clause. orderByCount is the number of ORDER BY items of the window, including
+// the ones of the named window it is based on.
+func (f frame) toSQL(orderByCount int) (string, error) {
+	if f.mode != RowsFrame && f.mode != RangeFrame {
+		return "", ErrInvalidWindow
+	}
+
+	if f.start.kind == 0 || f.end.kind == 0 ||
+		f.start.kind == unboundedFollowingBound || f.end.kind == unboundedPrecedingBound ||
+		f.start.kind > f.end.kind {
+		return "", ErrInvalidWindow
+	}
+
+	// RANGE with an offset needs exactly one ORDER BY column to measure the offset against.
+	if f.mode == RangeFrame && (f.start.hasOffset() || f.end.hasOffset()) && orderByCount != 1 {
+		return "", ErrInvalidWindow
+	}
+
+	return string(f.mode) + " BETWEEN " + f.start.String() + " AND " + f.end.String(), nil
+}
+
+// WindowExpression represents a window function call used as a select column,
+// e.g. RowNumber().Over(NewWindow().PartitionBy("accountId")).As("position").
+type WindowExpression struct {
+	function    WindowFunction
+	aggregation *dafi.Aggregation
+	field       string
+	offset      uint
+	window      Window
+	alias       string
+}
+
+// RowNumber creates a ROW_NUMBER() window expression.
+func RowNumber() WindowExpression {
+	return WindowExpression{function: RowNumberFunction}
+}
+
+// Rank creates a RANK() window expression.
+func Rank() WindowExpression {
+	return WindowExpression{function: RankFunction}
+}
+
+// DenseRank creates a DENSE_RANK() window expression.
+func DenseRank() WindowExpression {
+	return WindowExpression{function: DenseRankFunction}
+}
+
+// Lag creates a LAG window expression returning field from offset rows before the current row.
+// An offset of 0 uses the Postgres default of 1.
+func Lag(field string, offset uint) WindowExpression {
+	return WindowExpression{function: LagFunction, field: field, offset: offset}
+}
+
+// Lead creates a LEAD window expression returning field from offset rows after the current row.
+// An offset of 0 uses the Postgres default of 1.
+func Lead(field string, offset uint) WindowExpression {
+	return WindowExpression{function: LeadFunction, field: field, offset: offset}
+}
+
+// FirstValue creates a FIRST_VALUE window expression.
+func FirstValue(field string) WindowExpression {
+	return WindowExpression{function: FirstValueFunction, field: field}
+}
+
+// LastValue creates a LAST_VALUE window expression.
+func LastValue(field string) WindowExpression {
+	return WindowExpression{function: LastValueFunction, field: field}
+}
+
+// WindowAggregate evaluates an aggregation over a window, e.g. WindowAggregate(Sum("amount")) for running balances.
+// The alias of the aggregation is used as the column alias.
+func WindowAggregate(aggregation dafi.Aggregation) WindowExpression {
+	return WindowExpression{aggregation: &aggregation, alias: aggregation.Alias}
+}
+
+// Over sets the window the function is evaluated over.
+func (e WindowExpression) Over(window Window) WindowExpression {
+	e.window = window
+
+	return e
+}
+
+// As sets the column alias.
+func (e WindowExpression) As(alias string) WindowExpression {
+	e.alias = alias
+
+	return e
+}
+
+// toSQL builds the select column, e.g. SUM(amount) OVER (PARTITION BY account_id) AS balance.
+// definitions holds the named windows of the query.
+func (e WindowExpression) toSQL(state renderState, sqlColumnByDomainField map[string]string, definitions map[string]windowDefinition) (string, error) {
+	call, err := e.callSQL(state, sqlColumnByDomainField)
+	if err != nil {
+		return "", err
+	}
+
+	windowSQL, _, err := e.window.toSQL(state, sqlColumnByDomainField, definitions)
+	if err != nil {
+		return "", err
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(call)
+	builder.WriteString(" OVER ")
+	if e.window.isReference() {
+		builder.WriteString(windowSQL)
+	} else {
+		builder.WriteString("(")
+		builder.WriteString(windowSQL)
+		builder.WriteString(")")
+	}
+
+	if e.alias != "" {
+		if !isIdentifier(e.alias) {
+			return "", invalidIdentifier(e.alias)
+		}
+
+		builder.WriteString(" AS ")
+		builder.WriteString(e.alias)
+	}
+
+	return builder.String(), nil
+}
+
+// callSQL builds the function call before OVER.
+func (e WindowExpression) callSQL(state renderState, sqlColumnByDomainField map[string]string) (string, error) {
+	if e.aggregation != nil {
+		return buildAggregate(state, *e.aggregation, sqlColumnByDomainField)
+	}
+
+	switch e.function {
+	case RowNumberFunction, RankFunction, DenseRankFunction:
+		return string(e.function) + "()", nil
+	case LagFunction, LeadFunction, FirstValueFunction, LastValueFunction:
+		columns, err := resolveColumns(state, []string{e.field}, sqlColumnByDomainField)
+		if err != nil {
+			return "", err
+		}
+
+		arguments := columns[0]
+		if e.offset > 0 && (e.function == LagFunction || e.function == LeadFunction) {
+			arguments += ", " + strconv.FormatUint(uint64(e.offset), 10)
+		}
+
+		return string(e.function) + "(" + arguments + ")", nil
+	default:
+		return "", ErrInvalidOperator
+	}
+}
+
+// namedWindow represents an entry of the WINDOW clause.
+type namedWindow struct {
+	name   string
+	window Window
+}
+
+// buildWindowClause builds the WINDOW clause, e.g. WINDOW w AS (PARTITION BY account_id), and returns the
+// definitions of the named windows. A named window can only be based on the ones defined before it.
+func buildWindowClause(state renderState, windows []namedWindow, sqlColumnByDomainField map[string]string) (string, map[string]windowDefinition, error) {
+	definitions := make(map[string]windowDefinition, len(windows))
+	if len(windows) == 0 {
+		return "", definitions, nil
+	}
+
+	clauses := make([]string, 0, len(windows))
+	for _, named := range windows {
+		if !isIdentifier(named.name) {
+			return "", nil, invalidIdentifier(named.name)
+		}
+
+		if _, ok := definitions[named.name]; ok {
+			return "", nil, ErrInvalidWindow
+		}
+
+		windowSQL, definition, err := named.window.toSQL(state, sqlColumnByDomainField, definitions)
+		if err != nil {
+			return "", nil, err
+		}
+		definitions[named.name] = definition
+
+		clauses = append(clauses, named.name+" AS ("+windowSQL+")")
+	}
+
+	return " WINDOW " + strings.Join(clauses, ", "), definitions, nil
+}
+
+// resolveColumns converts domain fields to SQL columns. Every field must be mapped when a mapping
+// is provided; without one, strict mode requires safe identifiers.
+func resolveColumns(state renderState, fields []string, sqlColumnByDomainField map[string]string) ([]string, error) {
+	if len(sqlColumnByDomainField) == 0 {
+		for _, field := range fields {
+			if field == "" {
+				return nil, ErrInvalidFieldName
+			}
+		}
+
+		if err := state.validateIdentifiers(fields...); err != nil {
+			return nil, err
+		}
+
+		return fields, nil
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		column, ok := sqlColumnByDomainField[field]
+		if !ok {
+			return nil, invalidIdentifier(field)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
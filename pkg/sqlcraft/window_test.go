@@ -0,0 +1,241 @@
+package sqlcraft
+
+import (
+	"testing"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectQuery_Windows(t *testing.T) {
+	sqlColumnByDomainField := map[string]string{
+		"id":        "id",
+		"accountId": "account_id",
+		"amount":    "amount",
+		"createdAt": "created_at",
+		"currency":  "currency",
+	}
+
+	tests := []struct {
+		name    string
+		query   SelectQuery
+		want    Result
+		wantErr bool
+	}{
+		{
+			name: "running balance",
+			query: Select("id", "account_id", "amount").From("postings").
+				SQLColumnByDomainField(sqlColumnByDomainField).
+				Windowed(WindowAggregate(Sum("amount").As("balance")).Over(
+					NewWindow().PartitionBy("accountId").OrderBy(dafi.Sort{Field: "createdAt"}, dafi.Sort{Field: "id"}).Rows(UnboundedPreceding(), CurrentRow()),
+				)).
+				Where(dafi.Filter{Field: "currency", Value: "USD"}),
+			want: Result{
+				SQL:  "SELECT id, account_id, amount, SUM(amount) OVER (PARTITION BY account_id ORDER BY created_at, id ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS balance FROM postings WHERE currency = $1",
+				Args: []any{"USD"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "rank within account",
+			query: Select("id").From("postings").
+				SQLColumnByDomainField(sqlColumnByDomainField).
+				Windowed(
+					Rank().Over(NewWindow().PartitionBy("accountId").OrderBy(dafi.Sort{Field: "amount", Type: dafi.Desc})).As("position"),
+					Lag("amount", 0).Over(NewWindow().PartitionBy("accountId").OrderBy(dafi.Sort{Field: "createdAt"})).As("previous_amount"),
+					Lead("amount", 2).Over(NewWindow().OrderBy(dafi.Sort{Field: "createdAt"})),
+				),
+			want: Result{
+				SQL:  "SELECT id, RANK() OVER (PARTITION BY account_id ORDER BY amount DESC) AS position, LAG(amount) OVER (PARTITION BY account_id ORDER BY created_at) AS previous_amount, LEAD(amount, 2) OVER (ORDER BY created_at) FROM postings",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "named windows",
+			query: Select("id").From("postings").
+				SQLColumnByDomainField(sqlColumnByDomainField).
+				DefineWindow("by_account", NewWindow().PartitionBy("accountId")).
+				Windowed(
+					RowNumber().Over(NamedWindow("by_account")).As("rn"),
+					WindowAggregate(Avg("amount")).Over(NamedWindow("by_account").OrderBy(dafi.Sort{Field: "amount"}).Range(Preceding(100), CurrentRow())).As("avg_amount"),
+				).
+				OrderBy(dafi.Sort{Field: "id"}),
+			want: Result{
+				SQL:  "SELECT id, ROW_NUMBER() OVER by_account AS rn, AVG(amount) OVER (by_account ORDER BY amount RANGE BETWEEN 100 PRECEDING AND CURRENT ROW) AS avg_amount FROM postings WINDOW by_account AS (PARTITION BY account_id) ORDER BY id",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "frame on ordered named window",
+			query: Select("id").From("postings").
+				DefineWindow("w", NewWindow().PartitionBy("account_id").OrderBy(dafi.Sort{Field: "posted_at"})).
+				DefineWindow("recent", NamedWindow("w").Rows(Preceding(2), CurrentRow())).
+				Windowed(
+					WindowAggregate(Sum("amount")).Over(NamedWindow("w").Range(Preceding(1), CurrentRow())).As("daily"),
+					WindowAggregate(Avg("amount")).Over(NamedWindow("recent")).As("recent_avg"),
+				),
+			want: Result{
+				SQL:  "SELECT id, SUM(amount) OVER (w RANGE BETWEEN 1 PRECEDING AND CURRENT ROW) AS daily, AVG(amount) OVER recent AS recent_avg FROM postings WINDOW w AS (PARTITION BY account_id ORDER BY posted_at), recent AS (w ROWS BETWEEN 2 PRECEDING AND CURRENT ROW)",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty window",
+			query: Select("id").From("postings").
+				Windowed(WindowAggregate(Count("*")).Over(NewWindow()).As("total"), FirstValue("amount").Over(NewWindow().OrderBy(dafi.Sort{Field: "created_at"})).As("first_amount")),
+			want: Result{
+				SQL:  "SELECT id, COUNT(*) OVER () AS total, FIRST_VALUE(amount) OVER (ORDER BY created_at) AS first_amount FROM postings",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "latest rate per currency",
+			query: Select("currency", "rate").From("exchange_rates").
+				SQLColumnByDomainField(map[string]string{"currency": "currency", "effectiveAt": "effective_at"}).
+				DistinctOn("currency").
+				OrderBy(dafi.Sort{Field: "currency"}, dafi.Sort{Field: "effectiveAt", Type: dafi.Desc}),
+			want: Result{
+				SQL:  "SELECT DISTINCT ON (currency) currency, rate FROM exchange_rates ORDER BY currency, effective_at DESC",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "distinct on fields in any order",
+			query: Select("account_id", "currency", "amount").From("postings").
+				DistinctOn("account_id", "currency").
+				OrderBy(dafi.Sort{Field: "currency"}, dafi.Sort{Field: "account_id"}, dafi.Sort{Field: "created_at", Type: dafi.Desc}),
+			want: Result{
+				SQL:  "SELECT DISTINCT ON (account_id, currency) account_id, currency, amount FROM postings ORDER BY currency, account_id, created_at DESC",
+				Args: []any{},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error distinct on not leading the order by",
+			query:   Select("currency", "rate").From("exchange_rates").DistinctOn("currency").OrderBy(dafi.Sort{Field: "effective_at", Type: dafi.Desc}, dafi.Sort{Field: "currency"}),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error distinct on unmapped field",
+			query:   Select("id").From("postings").SQLColumnByDomainField(sqlColumnByDomainField).DistinctOn("account_id"),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error partition by unmapped field",
+			query:   Select("id").From("postings").SQLColumnByDomainField(sqlColumnByDomainField).Windowed(RowNumber().Over(NewWindow().PartitionBy("account_id"))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error frame start after end",
+			query:   Select("id").From("postings").Windowed(RowNumber().Over(NewWindow().Rows(CurrentRow(), Preceding(1)))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error frame starting unbounded following",
+			query:   Select("id").From("postings").Windowed(RowNumber().Over(NewWindow().Rows(UnboundedFollowing(), UnboundedFollowing()))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error range offset without single order by",
+			query:   Select("id").From("postings").Windowed(WindowAggregate(Sum("amount")).Over(NewWindow().Range(Preceding(1), CurrentRow()))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error partition override of named window",
+			query:   Select("id").From("postings").DefineWindow("w", NewWindow()).Windowed(RowNumber().Over(NamedWindow("w").PartitionBy("account_id"))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error order by override of named window",
+			query: Select("id").From("postings").
+				DefineWindow("w", NewWindow().OrderBy(dafi.Sort{Field: "posted_at"})).
+				Windowed(RowNumber().Over(NamedWindow("w").OrderBy(dafi.Sort{Field: "amount"}))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error frame override of named window",
+			query: Select("id").From("postings").
+				DefineWindow("w", NewWindow().OrderBy(dafi.Sort{Field: "posted_at"}).Rows(UnboundedPreceding(), CurrentRow())).
+				Windowed(WindowAggregate(Sum("amount")).Over(NamedWindow("w").Rows(Preceding(1), CurrentRow()))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error range offset on unordered named window",
+			query: Select("id").From("postings").
+				DefineWindow("w", NewWindow().PartitionBy("account_id")).
+				Windowed(WindowAggregate(Sum("amount")).Over(NamedWindow("w").Range(Preceding(1), CurrentRow()))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error undefined named window",
+			query:   Select("id").From("postings").Windowed(RowNumber().Over(NamedWindow("w"))),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name: "error named window defined later",
+			query: Select("id").From("postings").
+				DefineWindow("ordered", NamedWindow("w").OrderBy(dafi.Sort{Field: "posted_at"})).
+				DefineWindow("w", NewWindow().PartitionBy("account_id")),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error duplicate window name",
+			query:   Select("id").From("postings").DefineWindow("w", NewWindow()).DefineWindow("w", NewWindow()),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error invalid window name",
+			query:   Select("id").From("postings").DefineWindow("w; --", NewWindow()),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error invalid alias",
+			query:   Select("id").From("postings").Windowed(RowNumber().Over(NewWindow()).As("rn FROM accounts --")),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error lock with window",
+			query:   Select("id").From("postings").Windowed(RowNumber().Over(NewWindow())).ForUpdate(),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error lock with distinct on",
+			query:   Select("id").From("postings").DistinctOn("id").ForUpdate(),
+			want:    Result{},
+			wantErr: true,
+		},
+	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.render(renderState{dialect: dialect}, 0)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,266 @@
+package sqlcraft
+
+import (
+	"strings"
+)
+
+// CTE represents a single common table expression.
+// Name may include a column list, e.g. "tree(id, parent_id, depth)".
+type CTE struct {
+	Name  string
+	Query Query
+}
+
+// WithQuery represents a query prefixed by one or more common table expressions.
+type WithQuery struct {
+	recursive bool
+	ctes      []CTE
+	query     Query
+
+	dialect Dialect
+	lenient bool
+}
+
+// With creates a new WithQuery with the given common table expression.
+func With(name string, query Query) WithQuery {
+	return WithQuery{
+		ctes: []CTE{{Name: name, Query: query}},
+	}
+}
+
+// WithRecursive creates a new WITH RECURSIVE query with the given common table expression.
+func WithRecursive(name string, query Query) WithQuery {
+	w := With(name, query)
+	w.recursive = true
+
+	return w
+}
+
+// With adds another common table expression. Later expressions can reference earlier ones.
+func (w WithQuery) With(name string, query Query) WithQuery {
+	w.ctes = append(w.ctes, CTE{Name: name, Query: query})
+
+	return w
+}
+
+// Query sets the main statement that uses the common table expressions.
+// It can be any builder: SELECT, INSERT, UPDATE or DELETE.
+func (w WithQuery) Query(query Query) WithQuery {
+	w.query = query
+
+	return w
+}
+
+// Dialect sets the database the query is rendered for, Postgres by default.
+// Queries nested in it are always rendered with the dialect of the outermost query.
+func (w WithQuery) Dialect(dialect Dialect) WithQuery {
+	w.dialect = dialect
+
+	return w
+}
+
+// Lenient disables strict identifier validation for this query and the queries nested in it.
+// Only use it when every identifier is developer defined.
+func (w WithQuery) Lenient() WithQuery {
+	w.lenient = true
+
+	return w
+}
+
+// ToSQL builds the SQL query and returns the Result.
+func (w WithQuery) ToSQL() (Result, error) {
+	return w.render(renderState{}, 0)
+}
+
+func (w WithQuery) render(state renderState, initialArgCount int) (Result, error) {
+	state = state.nested(w.lenient, w.dialect)
+
+	if len(w.ctes) == 0 || w.query == nil {
+		return Result{}, ErrEmptyQuery
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString("WITH ")
+	if w.recursive {
+		builder.WriteString("RECURSIVE ")
+	}
+
+	args := []any{}
+	for i, cte := range w.ctes {
+		if cte.Name == "" {
+			return Result{}, ErrInvalidFieldName
+		}
+
+		if err := validateCTEName(state, cte.Name); err != nil {
+			return Result{}, err
+		}
+
+		subResult, err := renderSubquery(state, cte.Query, initialArgCount+len(args))
+		if err != nil {
+			return Result{}, err
+		}
+		args = append(args, subResult.Args...)
+
+		builder.WriteString(cte.Name)
+		builder.WriteString(" AS ")
+		builder.WriteString(subResult.SQL)
+
+		if i < len(w.ctes)-1 {
+			builder.WriteString(", ")
+		}
+	}
+
+	queryResult, err := w.query.render(state, initialArgCount+len(args))
+	if err != nil {
+		return Result{}, err
+	}
+
+	builder.WriteString(" ")
+	builder.WriteString(queryResult.SQL)
+	args = append(args, queryResult.Args...)
+
+	return Result{
+		SQL:  builder.String(),
+		Args: args,
+	}, nil
+}
+
+// validateCTEName checks a CTE name with an optional column list, e.g. "tree(id, parent_id)", in strict mode.
+func validateCTEName(state renderState, name string) error {
+	if state.lenient {
+		return nil
+	}
+
+	columnsStart := strings.IndexByte(name, '(')
+	if columnsStart < 0 {
+		if !isIdentifier(name) {
+			return invalidIdentifier(name)
+		}
+
+		return nil
+	}
+
+	if !isIdentifier(name[:columnsStart]) || !strings.HasSuffix(name, ")") {
+		return invalidIdentifier(name)
+	}
+
+	for _, column := range strings.Split(name[columnsStart+1:len(name)-1], ",") {
+		if !isIdentifier(strings.TrimSpace(column)) {
+			return invalidIdentifier(name)
+		}
+	}
+
+	return nil
+}
+
+// SetOperator represents the operator combining the queries of a CompoundQuery.
+type SetOperator string
+
+const (
+	// UnionOperator represents UNION.
+	UnionOperator SetOperator = "UNION"
+	// UnionAllOperator represents UNION ALL.
+	UnionAllOperator SetOperator = "UNION ALL"
+	// IntersectOperator represents INTERSECT.
+	IntersectOperator SetOperator = "INTERSECT"
+	// ExceptOperator represents EXCEPT.
+	ExceptOperator SetOperator = "EXCEPT"
+)
+
+// CompoundQuery represents queries combined with a set operator, e.g. the base and
+// recursive terms of a recursive CTE.
+type CompoundQuery struct {
+	operator SetOperator
+	queries  []Query
+
+	dialect Dialect
+	lenient bool
+}
+
+// Union combines the queries with UNION.
+func Union(queries ...Query) CompoundQuery {
+	return CompoundQuery{operator: UnionOperator, queries: queries}
+}
+
+// UnionAll combines the queries with UNION ALL.
+func UnionAll(queries ...Query) CompoundQuery {
+	return CompoundQuery{operator: UnionAllOperator, queries: queries}
+}
+
+// Intersect combines the queries with INTERSECT.
+func Intersect(queries ...Query) CompoundQuery {
+	return CompoundQuery{operator: IntersectOperator, queries: queries}
+}
+
+// Except combines the queries with EXCEPT.
+func Except(queries ...Query) CompoundQuery {
+	return CompoundQuery{operator: ExceptOperator, queries: queries}
+}
+
+// Dialect sets the database the query is rendered for, Postgres by default.
+// Queries nested in it are always rendered with the dialect of the outermost query.
+func (c CompoundQuery) Dialect(dialect Dialect) CompoundQuery {
+	c.dialect = dialect
+
+	return c
+}
+
+// Lenient disables strict identifier validation for this query and the queries nested in it.
+// Only use it when every identifier is developer defined.
+func (c CompoundQuery) Lenient() CompoundQuery {
+	c.lenient = true
+
+	return c
+}
+
+// ToSQL builds the SQL query and returns the Result.
+func (c CompoundQuery) ToSQL() (Result, error) {
+	return c.render(renderState{}, 0)
+}
+
+func (c CompoundQuery) render(state renderState, initialArgCount int) (Result, error) {
+	state = state.nested(c.lenient, c.dialect)
+
+	if len(c.queries) == 0 {
+		return Result{}, ErrEmptyQuery
+	}
+
+	if c.operator == IntersectOperator || c.operator == ExceptOperator {
+		if err := state.requireFeature(IntersectExceptFeature); err != nil {
+			return Result{}, err
+		}
+	}
+
+	builder := strings.Builder{}
+	args := []any{}
+
+	for i, query := range c.queries {
+		if query == nil {
+			return Result{}, ErrEmptyQuery
+		}
+
+		// Postgres does not allow locking clauses with set operations.
+		if selectQuery, ok := query.(SelectQuery); ok && !selectQuery.lock.isZero() {
+			return Result{}, ErrInvalidLock
+		}
+
+		queryResult, err := query.render(state, initialArgCount+len(args))
+		if err != nil {
+			return Result{}, err
+		}
+
+		builder.WriteString(queryResult.SQL)
+		args = append(args, queryResult.Args...)
+
+		if i < len(c.queries)-1 {
+			builder.WriteString(" ")
+			builder.WriteString(string(c.operator))
+			builder.WriteString(" ")
+		}
+	}
+
+	return Result{
+		SQL:  builder.String(),
+		Args: args,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package sqlcraft
+
+import (
+	"testing"
+
+	"backend.atomicledger.com/pkg/dafi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithQuery_ToSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   WithQuery
+		want    Result
+		wantErr bool
+	}{
+		{
+			name: "single cte",
+			query: With("active_accounts", Select("id", "name").From("accounts").Where(dafi.Filter{Field: "status", Value: "active"})).
+				Query(Select("id", "name").From("active_accounts").Where(dafi.Filter{Field: "name", Operator: dafi.Contains, Value: "cash"})),
+			want: Result{
+				SQL:  "WITH active_accounts AS (SELECT id, name FROM accounts WHERE status = $1) SELECT id, name FROM active_accounts WHERE name ILIKE $2",
+				Args: []any{"active", "%cash%"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "multiple ctes renumber placeholders",
+			query: With("a", Select("id").From("accounts").Where(dafi.Filter{Field: "currency", Value: "USD"})).
+				With("b", Select("id").From("a").Where(dafi.Filter{Field: "id", Operator: dafi.In, Value: []int{1, 2}})).
+				Query(Update("accounts").WithColumns("status").WithValues("frozen").Where(dafi.Filter{Field: "id", Operator: dafi.In, Value: Subquery(Select("id").From("b"))})),
+			want: Result{
+				SQL:  "WITH a AS (SELECT id FROM accounts WHERE currency = $1), b AS (SELECT id FROM a WHERE id IN ($2, $3)) UPDATE accounts SET status = $4 WHERE id IN (SELECT id FROM b)",
+				Args: []any{"USD", 1, 2, "frozen"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "recursive account tree",
+			query: WithRecursive("tree(id, parent_id, depth)", UnionAll(
+				Select("id", "parent_id", "0").From("accounts").Where(dafi.Filter{Field: "id", Value: 10}),
+				Select("a.id", "a.parent_id", "t.depth + 1").From("accounts a").InnerJoin("tree t", "a.parent_id = t.id").Where(dafi.Filter{Field: "t.depth", Operator: dafi.Less, Value: 5}),
+			)).Query(Select("id", "depth").From("tree").OrderBy(dafi.Sort{Field: "depth"})),
+			want: Result{
+				SQL:  "WITH RECURSIVE tree(id, parent_id, depth) AS (SELECT id, parent_id, 0 FROM accounts WHERE id = $1 UNION ALL SELECT a.id, a.parent_id, t.depth + 1 FROM accounts a INNER JOIN tree t ON a.parent_id = t.id WHERE t.depth < $2) SELECT id, depth FROM tree ORDER BY depth",
+				Args: []any{10, 5},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error missing main query",
+			query:   With("a", Select("id").From("accounts")),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error invalid cte query",
+			query:   With("a", Select().From("accounts")).Query(Select("id").From("a")),
+			want:    Result{},
+			wantErr: true,
+		},
+	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.render(renderState{dialect: dialect}, 0)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
+}
+
+func TestCompoundQuery_ToSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   CompoundQuery
+		want    Result
+		wantErr bool
+	}{
+		{
+			name: "union",
+			query: Union(
+				Select("id").From("assets").Where(dafi.Filter{Field: "currency", Value: "USD"}),
+				Select("id").From("liabilities").Where(dafi.Filter{Field: "currency", Value: "EUR"}),
+			),
+			want: Result{
+				SQL:  "SELECT id FROM assets WHERE currency = $1 UNION SELECT id FROM liabilities WHERE currency = $2",
+				Args: []any{"USD", "EUR"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "error locked member",
+			query:   Union(Select("id").From("assets").ForUpdate(), Select("id").From("liabilities")),
+			want:    Result{},
+			wantErr: true,
+		},
+		{
+			name:    "error empty",
+			query:   Except(),
+			want:    Result{},
+			wantErr: true,
+		},
+	}
+	forEachDialect(t, func(t *testing.T, dialect Dialect) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.query.render(renderState{dialect: dialect}, 0)
+				if tt.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				assertInDialect(t, dialect, tt.want, got, err)
+			})
+		}
+	})
+}
+
+func TestWith_NestedPlaceholders(t *testing.T) {
+	// Literal text that looks like a placeholder is never renumbered, e.g. in an E'' string.
+	query := With("recent", Select("id").From("postings p").
+		LeftJoin("memos m", `m.posting_id = p.id AND m.text <> E'\\'$1'`).
+		Where(dafi.Filter{Field: "p.amount", Operator: dafi.Greater, Value: 500})).
+		Query(Select("id").From("recent").Where(
+			dafi.Filter{Field: "id", Operator: dafi.In, Value: Subquery(Select("id").From("accounts").Where(dafi.Filter{Field: "type", Value: "expense"}))},
+			dafi.Filter{Field: "id", Operator: dafi.NotEqual, Value: 9},
+		))
+
+	got, err := query.ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, Result{
+		SQL: `WITH recent AS (SELECT id FROM postings p LEFT JOIN memos m ON m.posting_id = p.id AND m.text <> E'\\'$1' WHERE p.amount > $1) ` +
+			"SELECT id FROM recent WHERE id IN (SELECT id FROM accounts WHERE type = $2) AND id <> $3",
+		Args: []any{500, "expense", 9},
+	}, got)
+}
@@ -0,0 +1,165 @@
+package types
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/samber/oops"
+)
+
+type optionalState uint8
+
+const (
+	optionalUnset optionalState = iota
+	optionalNull
+	optionalValue
+)
+
+var jsonNull = []byte("null")
+
+// Optional is a generic value with three states: unset, null and value.
+// The zero value is unset, which lets PATCH requests tell an omitted field from an explicit null.
+// Use the omitzero json option to leave unset fields out of responses.
+type Optional[T any] struct {
+	value T
+	state optionalState
+}
+
+// Some creates an Optional holding value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, state: optionalValue}
+}
+
+// Null creates an Optional explicitly set to null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{state: optionalNull}
+}
+
+// IsSet returns true if the Optional holds a value or an explicit null.
+func (o Optional[T]) IsSet() bool {
+	return o.state != optionalUnset
+}
+
+// IsNull returns true if the Optional was explicitly set to null.
+func (o Optional[T]) IsNull() bool {
+	return o.state == optionalNull
+}
+
+// IsZero returns true if the Optional is unset, it is used by the omitzero json option.
+func (o Optional[T]) IsZero() bool {
+	return !o.IsSet()
+}
+
+// Get returns the value and true if the Optional holds a value.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.state == optionalValue
+}
+
+// Raw returns the value as any if the Optional holds one, otherwise nil.
+// It lets callers that do not know T, e.g. query builders, bind the underlying value.
+func (o Optional[T]) Raw() any {
+	if o.state != optionalValue {
+		return nil
+	}
+
+	return o.value
+}
+
+// Or returns the value if the Optional holds one, otherwise fallback.
+func (o Optional[T]) Or(fallback T) T {
+	if o.state != optionalValue {
+		return fallback
+	}
+
+	return o.value
+}
+
+// MarshalJSON encodes the value, unset and null are encoded as null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if o.state != optionalValue {
+		return jsonNull, nil
+	}
+
+	b, err := json.Marshal(o.value)
+	if err != nil {
+		return nil, oops.Wrapf(err, "failed to marshal Optional value")
+	}
+
+	return b, nil
+}
+
+// UnmarshalJSON decodes null as an explicit null and anything else as a value.
+// Fields missing from the payload are never decoded and stay unset.
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), jsonNull) {
+		*o = Null[T]()
+
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(b, &value); err != nil {
+		return oops.Wrapf(err, "failed to unmarshal Optional value")
+	}
+	*o = Some(value)
+
+	return nil
+}
+
+// UnmarshalParam decodes query, path and form parameters when binding with echo.
+// An empty parameter leaves the Optional unset, like a missing one. Strings are used as is and
+// other types are decoded as JSON, so null sets them to an explicit null.
+func (o *Optional[T]) UnmarshalParam(param string) error {
+	if param == "" {
+		*o = Optional[T]{}
+
+		return nil
+	}
+
+	if value, ok := any(param).(T); ok {
+		*o = Some(value)
+
+		return nil
+	}
+
+	return o.UnmarshalJSON([]byte(param))
+}
+
+// Value implements the driver.Valuer interface, unset and null are written as NULL.
+// Values implementing driver.Valuer are delegated to, any other value is returned as is so pgx
+// can encode it, e.g. slices, maps and structs.
+func (o Optional[T]) Value() (driver.Value, error) {
+	if o.state != optionalValue {
+		return nil, nil //nolint:nilnil // NULL is represented by a nil driver.Value
+	}
+
+	if valuer, ok := any(o.value).(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return nil, oops.Wrapf(err, "failed to convert Optional value")
+		}
+
+		return value, nil
+	}
+
+	return o.value, nil
+}
+
+// Scan implements the sql.Scanner interface, NULL is scanned as an explicit null.
+func (o *Optional[T]) Scan(src any) error {
+	var value sql.Null[T]
+	if err := value.Scan(src); err != nil {
+		return oops.Wrapf(err, "failed to scan Optional value")
+	}
+
+	if !value.Valid {
+		*o = Null[T]()
+
+		return nil
+	}
+	*o = Some(value.V)
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package types_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend.atomicledger.com/pkg/types"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type patchAccount struct {
+	Name        types.Optional[string]    `json:"name,omitzero"`
+	Description types.Optional[string]    `json:"description,omitzero"`
+	Limit       types.Optional[int64]     `json:"limit,omitzero"`
+	ClosedAt    types.Optional[time.Time] `json:"closedAt,omitzero"`
+}
+
+func TestOptional_States(t *testing.T) {
+	var unset types.Optional[string]
+	assert.False(t, unset.IsSet())
+	assert.False(t, unset.IsNull())
+	assert.True(t, unset.IsZero())
+	assert.Equal(t, "fallback", unset.Or("fallback"))
+
+	null := types.Null[string]()
+	assert.True(t, null.IsSet())
+	assert.True(t, null.IsNull())
+	_, ok := null.Get()
+	assert.False(t, ok)
+
+	some := types.Some("cash")
+	assert.True(t, some.IsSet())
+	assert.False(t, some.IsNull())
+	value, ok := some.Get()
+	assert.True(t, ok)
+	assert.Equal(t, "cash", value)
+	assert.Equal(t, "cash", some.Raw())
+	assert.Nil(t, null.Raw())
+	assert.Nil(t, unset.Raw())
+}
+
+func TestOptional_JSON(t *testing.T) {
+	var patch patchAccount
+	err := json.Unmarshal([]byte(`{"name":"cash","description":null,"limit":100}`), &patch)
+	assert.NoError(t, err)
+
+	assert.Equal(t, types.Some("cash"), patch.Name)
+	assert.Equal(t, types.Null[string](), patch.Description)
+	assert.Equal(t, types.Some(int64(100)), patch.Limit)
+	assert.False(t, patch.ClosedAt.IsSet())
+
+	b, err := json.Marshal(patch)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"cash","description":null,"limit":100}`, string(b))
+
+	err = json.Unmarshal([]byte(`{"limit":"abc"}`), &patch)
+	assert.Error(t, err)
+}
+
+// cents is a driver.Valuer stored as a decimal string.
+type cents int64
+
+func (c cents) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d.%02d", c/100, c%100), nil
+}
+
+func TestOptional_Value(t *testing.T) {
+	value, err := types.Some(7).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, 7, value)
+
+	value, err = types.Some([]string{"cash", "bank"}).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cash", "bank"}, value)
+
+	value, err = types.Some(patchAccount{Name: types.Some("cash")}).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, patchAccount{Name: types.Some("cash")}, value)
+
+	value, err = types.Some(cents(1050)).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.50", value)
+
+	value, err = types.Null[int]().Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = types.Optional[int]{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestOptional_Scan(t *testing.T) {
+	var limit types.Optional[int64]
+	assert.NoError(t, limit.Scan(int64(100)))
+	assert.Equal(t, types.Some(int64(100)), limit)
+
+	assert.NoError(t, limit.Scan(nil))
+	assert.Equal(t, types.Null[int64](), limit)
+
+	var name types.Optional[string]
+	assert.NoError(t, name.Scan([]byte("cash")))
+	assert.Equal(t, types.Some("cash"), name)
+
+	assert.Error(t, limit.Scan("abc"))
+}
+
+func TestOptional_EchoBind(t *testing.T) {
+	e := echo.New()
+
+	body := `{"name":"savings","description":null}`
+	req := httptest.NewRequest(http.MethodPatch, "/accounts/1?limit=250", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var patch patchAccount
+	assert.NoError(t, c.Bind(&patch))
+	assert.Equal(t, types.Some("savings"), patch.Name)
+	assert.Equal(t, types.Null[string](), patch.Description)
+	assert.False(t, patch.Limit.IsSet())
+	assert.False(t, patch.ClosedAt.IsSet())
+
+	var query struct {
+		Limit types.Optional[int64]  `query:"limit"`
+		Name  types.Optional[string] `query:"name"`
+		Note  types.Optional[string] `query:"note"`
+	}
+	req = httptest.NewRequest(http.MethodGet, "/accounts?limit=250&name=cash&note=", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+
+	assert.NoError(t, (&echo.DefaultBinder{}).BindQueryParams(c, &query))
+	assert.Equal(t, types.Some(int64(250)), query.Limit)
+	assert.Equal(t, types.Some("cash"), query.Name)
+	assert.False(t, query.Note.IsSet())
+
+	req = httptest.NewRequest(http.MethodGet, "/accounts?limit=null", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+
+	assert.NoError(t, (&echo.DefaultBinder{}).BindQueryParams(c, &query))
+	assert.Equal(t, types.Null[int64](), query.Limit)
+}